@@ -1,12 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/hiroyannnn/ruleforge/internal/auth"
+	"github.com/hiroyannnn/ruleforge/internal/check"
 	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/credentials"
 	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/fleet"
+	"github.com/hiroyannnn/ruleforge/internal/hooks"
+	"github.com/hiroyannnn/ruleforge/internal/layout"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/project"
+	"github.com/hiroyannnn/ruleforge/internal/promote"
+	"github.com/hiroyannnn/ruleforge/internal/report"
+	"github.com/hiroyannnn/ruleforge/internal/setup"
+	"github.com/hiroyannnn/ruleforge/internal/status"
 	"github.com/hiroyannnn/ruleforge/internal/updategeneral"
 	"github.com/hiroyannnn/ruleforge/internal/upload"
 	"github.com/hiroyannnn/ruleforge/internal/version"
@@ -21,14 +41,54 @@ var (
 )
 
 var (
-	configFile string
-	baseRepo   string
-	files      []string
-	message    string
-	verbose    bool
-	outputFile string
+	configFile  string
+	baseRepo    string
+	files       []string
+	message     string
+	verbose     bool
+	concurrency int
+	timeout     time.Duration
+	prOptions   config.PullRequestConfig
+
+	outputFormat string
+
+	// init の --output に指定された設定ファイルのパス（非推奨、--config を使う）
+	initOutputPath string
+
+	syncDeletions bool
+
+	updateGeneralOptions updategeneral.Options
+
+	fleetRepos     []string
+	fleetStateFile string
+	fleetOptions   fleet.Options
+
+	driftOptions report.DriftOptions
+
+	promoteOptions promote.Options
+
+	initOptions setup.Options
+
+	layoutOptions layout.Options
+
+	checkOptions check.Options
+
+	hooksOptions     hooks.Options
+	hooksRunDownload bool
+
+	authHost     string
+	authClientID string
+
+	showOrigin  bool
+	migrateDiff bool
 )
 
+// annotationOutput は --output json に対応するコマンドに付けるアノテーション
+const annotationOutput = "ruleforge/output"
+
+// supportsJSON は --output json に対応するコマンドの Annotations
+var supportsJSON = map[string]string{annotationOutput: output.FormatJSON}
+
 func init() {
 	// バージョン情報をバージョンパッケージに設定
 	version.CurrentVersion = buildVersion
@@ -37,11 +97,72 @@ func init() {
 }
 
 func main() {
+	rootCmd := newRootCmd()
+
+	// バージョンチェックを実行
+	go func() {
+		updateMsg, err := version.CheckForUpdates()
+		if err != nil {
+			// エラーは無視（ログに残さない）
+			return
+		}
+		if updateMsg != "" {
+			// 更新通知を表示
+			fmt.Fprintln(os.Stderr, updateMsg)
+		}
+	}()
+
+	// SIGINT/SIGTERM でコンテキストをキャンセルする
+	// 2回目のシグナルでは通常どおり即座に終了できるよう、最初のシグナル受信後に通知を解除する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// コマンド実行
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Fatalf("Error: 処理が中断されました: %v", err)
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Fatalf("Error: タイムアウト（%v）しました: %v", timeout, err)
+		}
+		log.Fatalf("Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+// newRootCmd はルートコマンドとサブコマンドを生成する
+func newRootCmd() *cobra.Command {
 	// ルートコマンド
 	rootCmd := &cobra.Command{
 		Use:     "ruleforge",
 		Short:   "AIエージェントのルール管理ツール",
 		Version: fmt.Sprintf("%s (commit: %s, built at: %s)", version.CurrentVersion, version.CurrentCommit, version.CurrentDate),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			initOutputPath = ""
+			if err := output.ValidateFormat(outputFormat); err != nil {
+				// 以前の init --output <ファイル> との互換性のため、ファイルのパスに見える値は生成する設定ファイルのパスとして扱う
+				// 形式の入力ミス（jsn など）で設定ファイルが作られないよう、それ以外は他のコマンドと同じエラーにする
+				if cmd.Name() != "init" || !looksLikeConfigPath(outputFormat) {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "警告: init の --output での設定ファイルのパスの指定は非推奨です。--config %s を使ってください\n", outputFormat)
+				initOutputPath = outputFormat
+				outputFormat = output.FormatText
+			}
+			if outputFormat != output.FormatJSON {
+				return nil
+			}
+			if cmd.Annotations[annotationOutput] != output.FormatJSON {
+				return fmt.Errorf("ruleforge %s は --output json に対応していません", commandName(cmd))
+			}
+			// 標準出力は JSON だけにするため、使い方とエラーは表示しない（エラーは JSON と標準エラー出力に含まれる）
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return nil
+		},
 	}
 
 	// フラグ定義
@@ -49,119 +170,702 @@ func main() {
 	rootCmd.PersistentFlags().StringVarP(&baseRepo, "base-repo", "b", "", "ベースリポジトリのURL")
 	rootCmd.PersistentFlags().StringSliceVarP(&files, "files", "f", []string{".cursor/rules.md"}, "対象ファイルのリスト")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "詳細なログ出力")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "コマンド全体のタイムアウト（例: 5m、0の場合は無制限）")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", output.FormatText, "出力形式（text, json）。json の場合は結果を標準出力に JSON で出力し、ログは標準エラー出力に出力")
 
 	// downloadコマンド
 	downloadCmd := &cobra.Command{
-		Use:   "download",
-		Short: "ベースリポジトリからエージェントルールをダウンロード",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := loadConfig()
+		Use:         "download",
+		Short:       "ベースリポジトリからエージェントルールをダウンロード",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			projects, err := loadProjects(cmd)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			return download.Execute(cfg)
-		},
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			var results []*output.Result
+			err = project.Run(ctx, projects, func(ctx context.Context, p *config.Project) error {
+				res, err := download.Execute(ctx, p.Config)
+				results = append(results, projectResult(projects, p, res))
+				return err
+			})
+			return results, err
+		}),
 	}
+	downloadCmd.Flags().IntVarP(&concurrency, "concurrency", "j", 0, "並列ダウンロード数（0の場合は設定ファイルの値を使用）")
 
 	// update-generalコマンド
 	updateGeneralCmd := &cobra.Command{
-		Use:   "update-general",
-		Short: "カレントリポジトリのエージェントルールをベースリポジトリのgeneralディレクトリに更新",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := loadConfig()
+		Use:         "update-general",
+		Short:       "カレントリポジトリのエージェントルールをベースリポジトリのgeneralディレクトリに更新",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadConfig(cmd)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			return updategeneral.Execute(cfg)
-		},
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			updateGeneralOptions.Out = textOut(cmd)
+			res, err := updategeneral.Execute(ctx, cfg, updateGeneralOptions)
+			return []*output.Result{res}, err
+		}),
 	}
 	updateGeneralCmd.Flags().StringVarP(&message, "message", "m", "", "PRのメッセージ")
 	if err := updateGeneralCmd.MarkFlagRequired("message"); err != nil {
 		log.Fatalf("Error marking flag as required: %v", err)
 	}
+	updateGeneralCmd.Flags().StringArrayVar(&updateGeneralOptions.Sections, "section", nil, "general に反映するセクションの見出し（複数指定可、未指定の場合はファイル全体）")
+	updateGeneralCmd.Flags().BoolVarP(&updateGeneralOptions.Pick, "pick", "i", false, "general に反映するセクションを対話的に選択")
+	addPullRequestFlags(updateGeneralCmd)
 
 	// uploadコマンド
 	uploadCmd := &cobra.Command{
-		Use:   "upload",
-		Short: "カレントリポジトリのエージェントルールをベースリポジトリにPRとして送信",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := loadConfig()
+		Use:         "upload",
+		Short:       "カレントリポジトリのエージェントルールをベースリポジトリにPRとして送信",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			projects, err := loadProjects(cmd)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			return upload.Execute(cfg)
-		},
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			var results []*output.Result
+			err = project.Run(ctx, projects, func(ctx context.Context, p *config.Project) error {
+				res, err := upload.Execute(ctx, p.Config)
+				results = append(results, projectResult(projects, p, res))
+				return err
+			})
+			return results, err
+		}),
 	}
 	uploadCmd.Flags().StringVarP(&message, "message", "m", "", "PRのメッセージ")
 	if err := uploadCmd.MarkFlagRequired("message"); err != nil {
 		log.Fatalf("Error marking flag as required: %v", err)
 	}
+	uploadCmd.Flags().BoolVar(&syncDeletions, "sync-deletions", false, "ローカルに存在しないファイルをベースリポジトリの <RepoName>/ 配下から削除")
+	addPullRequestFlags(uploadCmd)
+
+	// statusコマンド
+	statusCmd := &cobra.Command{
+		Use:         "status",
+		Short:       "ローカルのエージェントルールとベースリポジトリの差分をプロジェクトごとに表示",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			projects, err := loadProjects(cmd)
+			if err != nil {
+				return nil, err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			out := textOut(cmd)
+			summary := status.Summary{}
+			var results []*output.Result
+			err = project.Run(ctx, projects, func(ctx context.Context, p *config.Project) error {
+				if len(projects) > 1 {
+					fmt.Fprintf(out, "\n[%s]\n", p.Path)
+				}
+				statuses, err := status.Execute(ctx, p.Config, out)
+				summary.Add(statuses)
+
+				res := projectResult(projects, p, output.NewResult(""))
+				status.Record(res, p.Config, statuses)
+				results = append(results, res)
+				return res.Fail(err)
+			})
+			fmt.Fprintf(out, "\n%s\n", summary)
+			return results, err
+		}),
+	}
+
+	// checkコマンド
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "ローカルのエージェントルールがベースリポジトリ（またはロックファイル）と一致するかを確認（一致しなければ終了コード 1）",
+		Args:  cobra.NoArgs,
+		// 一致しないファイルの一覧を表示するため、使い方とエラーの重複は表示しない
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Annotations:   supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			// ロックファイルとの比較ではベースリポジトリにアクセスしないため、base-repo がなくても実行する
+			var projects []*config.Project
+			var err error
+			if checkOptions.Against == check.AgainstLock {
+				projects, err = config.LoadProjects(configFile, flagOverrides(cmd)...)
+				if err != nil {
+					return nil, fmt.Errorf("設定の読み込みに失敗: %w", err)
+				}
+			} else if projects, err = loadProjects(cmd); err != nil {
+				return nil, err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			checkOptions.Out = textOut(cmd)
+			checked, err := check.Execute(ctx, projects, checkOptions)
+			res := output.NewResult("")
+			check.Record(res, checked)
+			return []*output.Result{res}, res.Fail(err)
+		}),
+	}
+	checkCmd.Flags().StringVar(&checkOptions.Against, "against", check.AgainstBase, "比較の対象（base: ベースリポジトリ、lock: ロックファイル）")
 
 	// initコマンド
 	initCmd := &cobra.Command{
-		Use:   "init",
-		Short: "設定ファイルをカレントディレクトリに生成",
+		Use:         "init",
+		Short:       "エージェントのルールファイルを検出して設定ファイル（--config のパス）を生成",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			// 生成する設定ファイル自体は読み込まず、ユーザー設定ファイル・環境変数・フラグの値を使う
+			cfg, err := config.Load("", flagOverrides(cmd)...)
+			if err != nil {
+				return nil, fmt.Errorf("設定の読み込みに失敗: %w", err)
+			}
+
+			initOptions.BaseRepo = ""
+			if cmd.Flags().Changed("base-repo") {
+				initOptions.BaseRepo = baseRepo
+			}
+			initOptions.Files = nil
+			if cmd.Flags().Changed("files") {
+				initOptions.Files = files
+			}
+
+			initOptions.Output = configFile
+			if initOutputPath != "" {
+				initOptions.Output = initOutputPath
+			}
+			initOptions.Out = textOut(cmd)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			res, err := setup.Execute(ctx, cfg, initOptions)
+			return []*output.Result{res}, err
+		}),
+	}
+	initCmd.Flags().BoolVarP(&initOptions.Yes, "yes", "y", false, "対話的な入力をせず、フラグの値と検出したファイルで設定ファイルを生成")
+	initCmd.Flags().BoolVar(&initOptions.Force, "force", false, "既存の設定ファイルを上書き")
+
+	// fleetコマンド
+	fleetCmd := &cobra.Command{
+		Use:   "fleet",
+		Short: "複数の利用側リポジトリへのルール配布",
+	}
+
+	fleetSyncCmd := &cobra.Command{
+		Use:         "sync",
+		Short:       "ベースリポジトリのルールを利用側リポジトリにPRとして配布",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return nil, err
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			return fleet.Sync(ctx, cfg, fleetOptions)
+		}),
+	}
+	fleetSyncCmd.Flags().StringSliceVar(&fleetRepos, "repos", nil, "配布先リポジトリのリスト（owner/repo 形式、設定ファイルの fleet.repos を上書き）")
+	fleetSyncCmd.Flags().StringVar(&fleetStateFile, "state-file", "", "中断した同期を再開するための状態ファイルのパス")
+	fleetSyncCmd.Flags().BoolVar(&fleetOptions.Restart, "restart", false, "状態ファイルを無視して最初から同期")
+	fleetSyncCmd.Flags().BoolVar(&fleetOptions.DryRun, "dry-run", false, "PRを作成せず、同期が必要なリポジトリを表示")
+	fleetSyncCmd.Flags().StringVarP(&message, "message", "m", "", "コミットメッセージとPRのタイトル")
+	addPullRequestFlags(fleetSyncCmd)
+	fleetCmd.AddCommand(fleetSyncCmd)
+
+	// reportコマンド
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "利用側リポジトリのルール状況をレポート",
+	}
+
+	reportDriftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "ベースリポジトリのルールから遅れている利用側リポジトリを一覧表示",
+		// --output json では --format json のレポートをそのまま出力する
+		Annotations: supportsJSON,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+			if outputFormat == output.FormatJSON {
+				if cmd.Flags().Changed("format") && driftOptions.Format != report.FormatJSON {
+					return fmt.Errorf("--output json と --format %s は同時に指定できません", driftOptions.Format)
+				}
+				driftOptions.Format = report.FormatJSON
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			return report.Drift(ctx, cfg, driftOptions)
+		},
+	}
+	reportDriftCmd.Flags().StringVar(&driftOptions.Org, "org", "", "指定した組織の全リポジトリを対象にする")
+	reportDriftCmd.Flags().StringVar(&driftOptions.Topic, "topic", "", "指定したトピックを持つリポジトリに絞り込む（--org 未指定の場合はベースリポジトリの所有者のリポジトリから検索）")
+	reportDriftCmd.Flags().StringVar(&driftOptions.Format, "format", report.FormatMarkdown, "出力形式（markdown, json, csv）")
+	reportCmd.AddCommand(reportDriftCmd)
+
+	// promoteコマンド
+	promoteCmd := &cobra.Command{
+		Use:         "promote <repo-name>",
+		Short:       "ベースリポジトリ内のリポジトリ固有のルールを general に昇格するPRを作成",
+		Args:        cobra.ExactArgs(1),
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return nil, err
+			}
+			promoteOptions.RepoName = args[0]
+			promoteOptions.Out = textOut(cmd)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			res, err := promote.Execute(ctx, cfg, promoteOptions)
+			return []*output.Result{res}, err
+		}),
+	}
+	promoteCmd.Flags().StringArrayVar(&promoteOptions.Sections, "section", nil, "昇格するセクションの見出し（複数指定可、未指定の場合はファイル全体）")
+	promoteCmd.Flags().BoolVar(&promoteOptions.Remove, "remove", false, "昇格したルールをリポジトリのディレクトリから削除")
+	promoteCmd.Flags().BoolVarP(&promoteOptions.Yes, "yes", "y", false, "差分の確認を省略してPRを作成")
+	promoteCmd.Flags().StringVarP(&message, "message", "m", "", "コミットメッセージとPRのタイトル")
+	addPullRequestFlags(promoteCmd)
+
+	// layoutコマンド
+	layoutCmd := &cobra.Command{
+		Use:   "layout",
+		Short: "ベースリポジトリでのリポジトリ固有のルールの配置",
+	}
+
+	layoutMigrateCmd := &cobra.Command{
+		Use:         "migrate",
+		Short:       "カレントリポジトリのルールを <repo>/ から <owner>/<repo>/ に移動するPRを作成",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return nil, err
+			}
+			layoutOptions.Out = textOut(cmd)
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			res, err := layout.Execute(ctx, cfg, layoutOptions)
+			return []*output.Result{res}, err
+		}),
+	}
+	layoutMigrateCmd.Flags().BoolVar(&layoutOptions.Keep, "keep", false, "移動元の <repo>/ のファイルを削除せずに残す")
+	layoutMigrateCmd.Flags().BoolVarP(&layoutOptions.Yes, "yes", "y", false, "確認を省略してPRを作成")
+	addPullRequestFlags(layoutMigrateCmd)
+	layoutCmd.AddCommand(layoutMigrateCmd)
+
+	// hooksコマンド
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "ルールを最新に保つ git フックの管理",
+	}
+
+	hooksInstallCmd := &cobra.Command{
+		Use:         "install",
+		Short:       "post-checkout / post-merge / pre-push フックをインストール（既存のフックは先に実行）",
+		Args:        cobra.NoArgs,
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			hooksOptions.Out = textOut(cmd)
+			res, err := hooks.Install(".", hooksOptions)
+			return []*output.Result{res}, err
+		}),
+	}
+	hooksInstallCmd.Flags().BoolVar(&hooksOptions.Download, "download", false, "チェックアウトとマージの後に差分の警告ではなくルールをダウンロード")
+	hooksCmd.AddCommand(hooksInstallCmd)
+
+	hooksUninstallCmd := &cobra.Command{
+		Use:         "uninstall",
+		Short:       "ruleforge のフックを削除し、退避していたフックを元に戻す",
+		Args:        cobra.NoArgs,
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			res, err := hooks.Uninstall(".", textOut(cmd))
+			return []*output.Result{res}, err
+		}),
+	}
+	hooksCmd.AddCommand(hooksUninstallCmd)
+
+	// git のフックから呼び出されるコマンド
+	hooksRunCmd := &cobra.Command{
+		Use:    "run <hook> [-- args...]",
+		Short:  "git から呼び出されたフックの処理を実行",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		// git の出力に混ざるため、使い方とエラーの重複は表示しない
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return config.GenerateConfigFile(outputFile, baseRepo, files)
+			// pre-push はベースリポジトリにアクセスしないため、base-repo がなくても実行する
+			projects, err := config.LoadProjects(configFile, flagOverrides(cmd)...)
+			if err != nil {
+				return fmt.Errorf("設定の読み込みに失敗: %w", err)
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			return hooks.Run(ctx, args[0], args[1:], projects, hooks.RunOptions{
+				Download: hooksRunDownload,
+				Out:      cmd.ErrOrStderr(),
+			})
 		},
 	}
-	initCmd.Flags().StringVarP(&outputFile, "output", "o", ".ruleforge.yaml", "出力する設定ファイルのパス")
+	hooksRunCmd.Flags().BoolVar(&hooksRunDownload, "download", false, "ルールをダウンロード")
+	hooksCmd.AddCommand(hooksRunCmd)
+
+	// login/logout/authコマンド
+	loginCmd := &cobra.Command{
+		Use:         "login",
+		Short:       "OAuth のデバイスフローで GitHub にログインし、トークンを保存",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadSettings(cmd)
+			if err != nil {
+				return nil, err
+			}
+
+			clientID := cfg.Auth.OAuthClientID
+			if authClientID != "" {
+				clientID = authClientID
+			}
+
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			res, err := auth.Login(ctx, auth.LoginOptions{
+				Host:     resolveAuthHost(cfg),
+				ClientID: clientID,
+				Out:      textOut(cmd),
+			})
+			return []*output.Result{res}, err
+		}),
+	}
+	loginCmd.Flags().StringVar(&authHost, "hostname", "", "ログインするホスト（未指定の場合はベースリポジトリのホスト）")
+	loginCmd.Flags().StringVar(&authClientID, "client-id", "", "OAuth App のクライアントID（設定ファイルの auth.oauth-client-id を上書き）")
+
+	logoutCmd := &cobra.Command{
+		Use:         "logout",
+		Short:       "保存したトークンを削除",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadSettings(cmd)
+			if err != nil {
+				return nil, err
+			}
+			res, err := auth.Logout(resolveAuthHost(cfg), textOut(cmd))
+			return []*output.Result{res}, err
+		}),
+	}
+	logoutCmd.Flags().StringVar(&authHost, "hostname", "", "ログアウトするホスト（未指定の場合はベースリポジトリのホスト）")
+
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "GitHub の認証情報の管理",
+	}
+
+	authStatusCmd := &cobra.Command{
+		Use:         "status",
+		Short:       "使用するトークンの取得元とログイン済みのホストを表示",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadSettings(cmd)
+			if err != nil {
+				return nil, err
+			}
+			host := resolveAuthHost(cfg)
+			res, err := auth.Status(host, configTokenSource(cfg, host), textOut(cmd))
+			return []*output.Result{res}, err
+		}),
+	}
+	authStatusCmd.Flags().StringVar(&authHost, "hostname", "", "確認するホスト（未指定の場合はベースリポジトリのホスト）")
+	authCmd.AddCommand(authStatusCmd)
+
+	// configコマンド
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "設定の確認と検証",
+	}
+
+	configShowCmd := &cobra.Command{
+		Use:         "show",
+		Short:       "ユーザー設定ファイル、設定ファイル、環境変数、フラグを重ねた有効な設定を表示",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			cfg, err := loadSettings(cmd)
+			if err != nil {
+				return nil, err
+			}
+			res, err := config.Show(textOut(cmd), cfg, showOrigin)
+			return []*output.Result{res}, err
+		}),
+	}
+	configShowCmd.Flags().BoolVar(&showOrigin, "origin", false, "各設定値の取得元を表示")
+	configCmd.AddCommand(configShowCmd)
+
+	configValidateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "設定ファイルの不明なキーや不正な値をすべて報告",
+		// 問題の一覧を表示するため、使い方は表示しない
+		SilenceUsage: true,
+		Annotations:  supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			// 結果には問題の一覧と、古い形式の設定ファイルを記録する
+			res := output.NewResult("")
+			out := textOut(cmd)
+			cfg, err := config.Load(configFile, flagOverrides(cmd)...)
+			var validationErr *config.ValidationError
+			if errors.As(err, &validationErr) {
+				for _, p := range validationErr.Problems {
+					fmt.Fprintln(out, p)
+					res.Problems = append(res.Problems, output.Problem{Key: p.Key, Location: p.Location, Message: p.Message})
+				}
+				return []*output.Result{res}, res.Fail(fmt.Errorf("設定に %d 件の問題があります", len(validationErr.Problems)))
+			}
+			if err != nil {
+				return []*output.Result{res}, res.Fail(fmt.Errorf("設定の読み込みに失敗: %w", err))
+			}
+			fmt.Fprintln(out, "設定に問題は見つかりませんでした")
+			for _, file := range cfg.Outdated {
+				res.Files = append(res.Files, output.File{Path: file, Status: output.StatusOutdated})
+				fmt.Fprintf(out, "%s は古い形式です。ruleforge config migrate で更新できます\n", file)
+			}
+			return []*output.Result{res}, nil
+		}),
+	}
+	configCmd.AddCommand(configValidateCmd)
+
+	configSchemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "設定ファイルのJSON Schema（エディタの補完用）を出力",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := config.JSONSchema()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+	configCmd.AddCommand(configSchemaCmd)
+
+	configMigrateCmd := &cobra.Command{
+		Use:         "migrate",
+		Short:       "古い形式の設定ファイルを現在の形式に更新（コメントは保持）",
+		Annotations: supportsJSON,
+		RunE: withResults(func(cmd *cobra.Command, args []string) ([]*output.Result, error) {
+			res, err := config.MigrateFile(configFile, textOut(cmd), migrateDiff)
+			return []*output.Result{res}, err
+		}),
+	}
+	configMigrateCmd.Flags().BoolVar(&migrateDiff, "diff", false, "ファイルを書き換えずに差分を表示")
+	configCmd.AddCommand(configMigrateCmd)
 
 	// コマンド追加
 	rootCmd.AddCommand(downloadCmd)
 	rootCmd.AddCommand(updateGeneralCmd)
 	rootCmd.AddCommand(uploadCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(fleetCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(promoteCmd)
+	rootCmd.AddCommand(layoutCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(configCmd)
 
-	// バージョンチェックを実行
-	go func() {
-		updateMsg, err := version.CheckForUpdates()
-		if err != nil {
-			// エラーは無視（ログに残さない）
-			return
+	return rootCmd
+}
+
+// addPullRequestFlags はPRを作成するコマンドにラベルやレビュアーのフラグを追加する
+func addPullRequestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&prOptions.Labels, "label", nil, "PRに付与するラベル")
+	cmd.Flags().StringSliceVar(&prOptions.Reviewers, "reviewer", nil, "レビューを依頼するユーザー")
+	cmd.Flags().StringSliceVar(&prOptions.TeamReviewers, "team-reviewer", nil, "レビューを依頼するチーム（slug）")
+	cmd.Flags().StringSliceVar(&prOptions.Assignees, "assignee", nil, "PRにアサインするユーザー")
+	cmd.Flags().BoolVar(&prOptions.Draft, "draft", false, "PRをドラフトとして作成")
+	cmd.Flags().StringVar(&prOptions.Milestone, "milestone", "", "PRに設定するマイルストーン（タイトルまたは番号）")
+}
+
+// withResults は結果を返すコマンドの RunE を作成する
+// --output json の場合は結果を標準出力に JSON で出力する（設定の読み込みなどで失敗した場合もエラーを含めて出力する）
+// 終了コードのため、エラーは JSON を出力した後もそのまま返す
+func withResults(run func(cmd *cobra.Command, args []string) ([]*output.Result, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		results, err := run(cmd, args)
+		if outputFormat != output.FormatJSON {
+			return err
 		}
-		if updateMsg != "" {
-			// 更新通知を表示
-			fmt.Fprintln(os.Stderr, updateMsg)
+		if werr := output.Write(cmd.OutOrStdout(), commandName(cmd), results, err); werr != nil {
+			return errors.Join(err, werr)
 		}
-	}()
+		return err
+	}
+}
 
-	// コマンド実行
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("Error: %v", err)
-		os.Exit(1)
+// projectResult はモノレポで複数のプロジェクトを処理する場合に、結果にプロジェクトのパスを設定する
+func projectResult(projects []*config.Project, p *config.Project, res *output.Result) *output.Result {
+	if len(projects) > 1 {
+		res.Project = p.Path
 	}
+	return res
 }
 
-// 設定を読み込む
-func loadConfig() (*config.Config, error) {
-	cfg, err := config.Load(configFile)
+// textOut は表や確認のプロンプトの出力先を返す
+// --output json の場合は標準出力を JSON だけにするため、標準エラー出力を使う
+func textOut(cmd *cobra.Command) io.Writer {
+	if outputFormat == output.FormatJSON {
+		return cmd.ErrOrStderr()
+	}
+	return cmd.OutOrStdout()
+}
+
+// commandName はルートコマンドを除いたコマンド名（例: "layout migrate"）を返す
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// resolveAuthHost は --hostname、ベースリポジトリのホストの順に認証するホストを決める
+func resolveAuthHost(cfg *config.Config) string {
+	if authHost != "" {
+		return authHost
+	}
+	return credentials.HostFromRepoURL(cfg.BaseRepo)
+}
+
+// looksLikeConfigPath は init の --output の値が出力形式ではなく設定ファイルのパスに見えるかを返す
+func looksLikeConfigPath(value string) bool {
+	ext := strings.ToLower(filepath.Ext(value))
+	return ext == ".yaml" || ext == ".yml" || strings.ContainsAny(value, `/\`)
+}
+
+// configTokenSource は設定で指定した認証情報の取得元を返す
+// 設定の認証情報はベースリポジトリのホストにだけ使うため、ほかのホストの場合は空を返す
+func configTokenSource(cfg *config.Config, host string) string {
+	if host != credentials.HostFromRepoURL(cfg.BaseRepo) {
+		return ""
+	}
+	if cfg.Auth.App.Enabled() {
+		return fmt.Sprintf("GitHub App (App ID: %d)", cfg.Auth.App.AppID)
+	}
+	return cfg.TokenSource
+}
+
+// commandContext はコマンドのコンテキストに --timeout を適用する
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// loadSettings はユーザー設定ファイル、設定ファイル、環境変数、コマンドで指定されたフラグを重ねて設定を読み込む
+func loadSettings(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := config.Load(configFile, flagOverrides(cmd)...)
 	if err != nil {
 		return nil, fmt.Errorf("設定の読み込みに失敗: %w", err)
 	}
+	return cfg, nil
+}
 
-	// コマンドライン引数で上書き
-	if baseRepo != "" {
-		cfg.BaseRepo = baseRepo
+// flagOverrides はコマンドラインで明示的に指定されたフラグを設定の上書きに変換する
+// 指定されていないフラグはデフォルト値であっても設定ファイルや環境変数の値を上書きしない
+func flagOverrides(cmd *cobra.Command) []config.Override {
+	var overrides []config.Override
+	add := func(flag, key string, value any) {
+		if f := cmd.Flags().Lookup(flag); f != nil && f.Changed {
+			overrides = append(overrides, config.Override{Key: key, Value: value, Origin: "フラグ --" + flag})
+		}
 	}
 
-	if len(files) > 0 && !(len(files) == 1 && files[0] == ".cursor/rules.md") {
-		cfg.Files = files
+	add("base-repo", "base-repo", baseRepo)
+	add("files", "target-files", files)
+	add("verbose", "verbose", verbose)
+	add("message", "message", message)
+	add("concurrency", "concurrency", concurrency)
+	add("sync-deletions", "sync-deletions", syncDeletions)
+	add("label", "pull-request.labels", prOptions.Labels)
+	add("reviewer", "pull-request.reviewers", prOptions.Reviewers)
+	add("team-reviewer", "pull-request.team-reviewers", prOptions.TeamReviewers)
+	add("assignee", "pull-request.assignees", prOptions.Assignees)
+	add("draft", "pull-request.draft", prOptions.Draft)
+	add("milestone", "pull-request.milestone", prOptions.Milestone)
+	add("repos", "fleet.repos", fleetRepos)
+	add("state-file", "fleet.state-file", fleetStateFile)
+	return overrides
+}
+
+// 設定を読み込む
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := loadSettings(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConfig(cfg); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
 
-	if message != "" {
-		cfg.Message = message
+// loadProjects はモノレポのルートとサブプロジェクトの設定を読み込む
+func loadProjects(cmd *cobra.Command) ([]*config.Project, error) {
+	projects, err := config.LoadProjects(configFile, flagOverrides(cmd)...)
+	if err != nil {
+		return nil, fmt.Errorf("設定の読み込みに失敗: %w", err)
+	}
+	if err := checkConfig(projects[0].Config); err != nil {
+		return nil, err
 	}
+	return projects, nil
+}
 
-	cfg.Verbose = verbose
+// checkConfig は古い形式の設定ファイルを表示し、必須項目を検証する
+// 認証情報の取得元は GitHub クライアントの作成時に表示する
+func checkConfig(cfg *config.Config) error {
+	if cfg.Verbose {
+		for _, file := range cfg.Outdated {
+			log.Printf("設定ファイル %s は古い形式です。ruleforge config migrate で更新できます", file)
+		}
+	}
 
 	// 必須項目の検証
 	if cfg.BaseRepo == "" {
-		return nil, fmt.Errorf("ベースリポジトリURLが指定されていません。--base-repo フラグまたは設定ファイルで指定してください")
+		return fmt.Errorf("ベースリポジトリURLが指定されていません。--base-repo フラグ、環境変数 %s または設定ファイルで指定してください", config.EnvName("base-repo"))
 	}
 
-	return cfg, nil
+	return nil
 }
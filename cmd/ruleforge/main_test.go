@@ -1,11 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/spf13/cobra"
 )
 
+// parseCommand はコマンドラインを解析し、実行されるサブコマンドを返す
+func parseCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd, rest, err := newRootCmd().Find(args)
+	if err != nil {
+		t.Fatalf("コマンドが見つかりません: %v", err)
+	}
+	if err := cmd.ParseFlags(rest); err != nil {
+		t.Fatalf("フラグの解析に失敗: %v", err)
+	}
+	return cmd
+}
+
 func TestLoadConfig(t *testing.T) {
 	// テスト用の一時ディレクトリを作成
 	tempDir, err := os.MkdirTemp("", "ruleforge-test-*")
@@ -49,15 +68,12 @@ message: "Test message"
 		verbose = origVerbose
 	}()
 
-	// テスト用の値を設定
-	configFile = testConfigPath
-	baseRepo = ""
-	files = []string{".cursor/rules.md"}
-	message = ""
-	verbose = false
+	// 利用者のユーザー設定ファイルを読み込まない
+	t.Setenv("RULEFORGE_CONFIG_DIR", tempDir)
 
-	// テスト実行
-	cfg, err := loadConfig()
+	// テスト実行（フラグで上書きしない場合は設定ファイルの値が使われる）
+	cmd := parseCommand(t, "upload", "--config", testConfigPath)
+	cfg, err := loadConfig(cmd)
 	if err != nil {
 		t.Fatalf("設定の読み込みに失敗: %v", err)
 	}
@@ -71,8 +87,8 @@ message: "Test message"
 		t.Errorf("Files: 期待値 [.cursor/rules.md .cursor/config.json], 実際の値 %v", cfg.Files)
 	}
 
-	if cfg.GitHubToken != "test-token-value" {
-		t.Errorf("GitHubToken: 期待値 %s, 実際の値 %s", "test-token-value", cfg.GitHubToken)
+	if cfg.Auth.GitHubToken != "test-token-value" {
+		t.Errorf("GitHubToken: 期待値 %s, 実際の値 %s", "test-token-value", cfg.Auth.GitHubToken)
 	}
 
 	if cfg.Message != "Test message" {
@@ -122,15 +138,18 @@ message: "File message"
 		verbose = origVerbose
 	}()
 
-	// テスト用の値を設定 (コマンドライン引数でオーバーライド)
-	configFile = testConfigPath
-	baseRepo = "https://github.com/override/repo"
-	files = []string{".cursor/rules.md", ".cursor/settings.json"}
-	message = "CLI message"
-	verbose = true
+	// 利用者のユーザー設定ファイルを読み込まない
+	t.Setenv("RULEFORGE_CONFIG_DIR", tempDir)
 
-	// テスト実行
-	cfg, err := loadConfig()
+	// テスト実行 (コマンドライン引数でオーバーライド)
+	cmd := parseCommand(t, "upload",
+		"--config", testConfigPath,
+		"--base-repo", "https://github.com/override/repo",
+		"--files", ".cursor/rules.md,.cursor/settings.json",
+		"--message", "CLI message",
+		"--verbose",
+	)
+	cfg, err := loadConfig(cmd)
 	if err != nil {
 		t.Fatalf("設定の読み込みに失敗: %v", err)
 	}
@@ -165,12 +184,235 @@ func TestLoadConfigError(t *testing.T) {
 	}()
 
 	// 設定ファイルなし、ベースリポジトリ指定なしの場合エラーになることを確認
-	configFile = "nonexistent-file.yaml"
-	baseRepo = ""
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("RULEFORGE_BASE_REPO", "")
+	cmd := parseCommand(t, "download", "--config", "nonexistent-file.yaml")
 
 	// テスト実行
-	_, err := loadConfig()
+	_, err := loadConfig(cmd)
 	if err == nil {
 		t.Errorf("エラーが期待されましたが、成功してしまいました")
 	}
 }
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	userDir := filepath.Join(dir, "user")
+	if err := os.MkdirAll(userDir, 0700); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	userConfig := `base-repo: https://github.com/user/repo
+message: "User message"
+concurrency: 2
+pull-request:
+  labels: [user-label]
+`
+	if err := os.WriteFile(filepath.Join(userDir, "config.yaml"), []byte(userConfig), 0600); err != nil {
+		t.Fatalf("ユーザー設定ファイルの作成に失敗: %v", err)
+	}
+	repoConfigPath := filepath.Join(dir, ".ruleforge.yaml")
+	repoConfig := `message: "Repo message"
+concurrency: 3
+`
+	if err := os.WriteFile(repoConfigPath, []byte(repoConfig), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+
+	t.Setenv("RULEFORGE_CONFIG_DIR", userDir)
+	t.Setenv("RULEFORGE_CONCURRENCY", "5")
+	t.Setenv("RULEFORGE_PULL_REQUEST_LABELS", "env-a, env-b")
+
+	// デフォルト値と同じ値でも、明示的に指定したフラグは設定ファイルより優先する
+	cmd := parseCommand(t, "upload", "--config", repoConfigPath, "--files", ".cursor/rules.md", "--reviewer", "octocat")
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+
+	testCases := []struct {
+		key      string
+		actual   interface{}
+		expected interface{}
+		origin   string
+	}{
+		{"base-repo", cfg.BaseRepo, "https://github.com/user/repo", "ユーザー設定ファイル (" + filepath.Join(userDir, "config.yaml") + ":1)"},
+		{"message", cfg.Message, "Repo message", "設定ファイル (" + repoConfigPath + ":1)"},
+		{"concurrency", cfg.Concurrency, 5, "環境変数 RULEFORGE_CONCURRENCY"},
+		{"pull-request.labels", strings.Join(cfg.PullRequest.Labels, ","), "env-a,env-b", "環境変数 RULEFORGE_PULL_REQUEST_LABELS"},
+		{"pull-request.reviewers", strings.Join(cfg.PullRequest.Reviewers, ","), "octocat", "フラグ --reviewer"},
+		{"target-files", strings.Join(cfg.Files, ","), ".cursor/rules.md", "フラグ --files"},
+	}
+	for _, tc := range testCases {
+		if tc.actual != tc.expected {
+			t.Errorf("%s: 期待値 %v, 実際の値 %v", tc.key, tc.expected, tc.actual)
+		}
+		if got := cfg.Origins[tc.key]; got != tc.origin {
+			t.Errorf("%s の取得元: 期待値 %s, 実際の値 %s", tc.key, tc.origin, got)
+		}
+	}
+}
+
+func TestConfigShowOrigin(t *testing.T) {
+	t.Setenv("RULEFORGE_AUTH_GITHUB_TOKEN", "secret-token")
+	// gh CLI などの認証情報は API を使うときにだけ探すため、config show には表示されない
+	t.Setenv("GITHUB_TOKEN", "env-token")
+
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"config", "show", "--origin", "--config", "nonexistent-file.yaml", "--base-repo", "https://github.com/flag/repo"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("config show に失敗: %v", err)
+	}
+
+	output := out.String()
+	if strings.Contains(output, "secret-token") {
+		t.Errorf("トークンが表示されました:\n%s", output)
+	}
+	// キーごとに値と取得元が1行に表示される
+	for _, want := range [][]string{
+		{"base-repo", "https://github.com/flag/repo", "フラグ --base-repo"},
+		{"auth.github-token", "********", "環境変数 RULEFORGE_AUTH_GITHUB_TOKEN"},
+		{"concurrency", "4", "デフォルト値"},
+	} {
+		found := false
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 2 && fields[0] == want[0] && fields[1] == want[1] && strings.Join(fields[2:], " ") == want[2] {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("出力に %v の行がありません:\n%s", want, output)
+		}
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "nonexistent-file.yaml")
+
+	// 失敗した場合もエラーを含む JSON だけを標準出力に出力する
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"check", "--against", "lock", "--output", "json", "--config", configPath, "--files", "AGENTS.md"})
+	if err := root.Execute(); err == nil {
+		t.Fatal("ロックファイルがないエラーが期待されました")
+	}
+
+	var report output.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("標準出力が JSON ではありません: %v\n%s", err, out.String())
+	}
+	if report.Command != "check" || report.OK || !strings.Contains(report.Error, "ロックファイル") {
+		t.Errorf("結果が期待と異なります: %+v", report)
+	}
+
+	// JSON に対応していないコマンドはエラー
+	root = newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"config", "schema", "-o", "json", "--config", configPath})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "config schema は --output json に対応していません") {
+		t.Errorf("対応していないエラーが期待されました: %v", err)
+	}
+}
+
+func TestConfigValidateOutputJSON(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	if err := os.WriteFile(configPath, []byte("base-repo: org/rules\nunknown-key: 1\n"), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+
+	// 問題の一覧は JSON の結果に含め、標準出力には JSON だけを出力する
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"config", "validate", "--output", "json", "--config", configPath})
+	if err := root.Execute(); err == nil {
+		t.Fatal("設定の問題によるエラーが期待されました")
+	}
+
+	var report output.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("標準出力が JSON ではありません: %v\n%s", err, out.String())
+	}
+	if report.Command != "config validate" || report.OK || len(report.Results) != 1 {
+		t.Fatalf("結果が期待と異なります: %+v", report)
+	}
+	problems := report.Results[0].Problems
+	if len(problems) != 1 || problems[0].Key != "unknown-key" || !strings.Contains(problems[0].Location, configPath) {
+		t.Errorf("問題の一覧が期待と異なります: %+v", problems)
+	}
+}
+
+func TestAuthStatusReportsConfigToken(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "env-token")
+	t.Setenv("RULEFORGE_AUTH_GITHUB_TOKEN", "config-token")
+
+	// 実行時に優先される RULEFORGE_AUTH_GITHUB_TOKEN を取得元として表示する
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"auth", "status", "-o", "json", "--config", filepath.Join(t.TempDir(), "none.yaml"), "--base-repo", "org/rules"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("auth status に失敗: %v", err)
+	}
+
+	var report output.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("標準出力が JSON ではありません: %v\n%s", err, out.String())
+	}
+	if len(report.Results) != 1 || report.Results[0].Auth == nil || report.Results[0].Auth.TokenSource != "環境変数 RULEFORGE_AUTH_GITHUB_TOKEN" {
+		t.Errorf("トークンの取得元が期待と異なります: %+v", report.Results)
+	}
+}
+
+func TestInitOutputPathCompat(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	// 以前の init --output <ファイル> は、警告を出したうえで生成する設定ファイルのパスとして扱う
+	existing := filepath.Join(t.TempDir(), "existing.yaml")
+	if err := os.WriteFile(existing, []byte("base-repo: org/rules\n"), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&stderr)
+	root.SetArgs([]string{"init", "-o", existing, "--yes"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "設定ファイル "+existing+" は既に存在します") {
+		t.Errorf("--output のパスの既存ファイルのエラーが期待されました: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "非推奨") {
+		t.Errorf("非推奨の警告が出力されていません: %s", stderr.String())
+	}
+}
+
+func TestInitOutputFormatTypo(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	// ファイルのパスに見えない値は、出力形式の入力ミスとしてエラーにする
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"init", "--output", "jsn", "--yes"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), `出力形式 "jsn" には対応していません`) {
+		t.Errorf("出力形式のエラーが期待されました: %v", err)
+	}
+	if _, err := os.Stat("jsn"); !os.IsNotExist(err) {
+		t.Errorf("入力ミスの値で設定ファイルが作成されました: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/credentials"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"golang.org/x/oauth2"
+)
+
+// ログイン時に要求するスコープ（ブランチ・PRの作成と組織のリポジトリ一覧の取得）
+var defaultScopes = []string{"repo", "read:org"}
+
+// LoginOptions は login コマンドの実行オプション
+type LoginOptions struct {
+	// ログインするホスト（github.com または GitHub Enterprise Server のホスト）
+	Host string
+
+	// OAuth App のクライアントID
+	ClientID string
+
+	// 出力先（nilの場合は標準出力）
+	Out io.Writer
+
+	// テスト用にWebとAPIのURLを差し替える（空の場合はホストから求める）
+	webURL string
+	apiURL string
+}
+
+// Login は OAuth のデバイスフローでトークンを取得し、認証情報ファイルに保存する
+// 結果にはログインしたホストとユーザーを記録する
+func Login(ctx context.Context, opts LoginOptions) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(login(ctx, opts, res))
+}
+
+func login(ctx context.Context, opts LoginOptions, res *output.Result) error {
+	if opts.ClientID == "" {
+		return fmt.Errorf("OAuth App のクライアントIDが指定されていません。--client-id フラグまたは設定ファイルの auth.oauth-client-id で指定してください")
+	}
+	host := hostOrDefault(opts.Host)
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	webURL, apiURL := opts.webURL, opts.apiURL
+	if webURL == "" {
+		webURL = "https://" + host
+	}
+	if apiURL == "" {
+		apiURL = defaultAPIURL(host)
+	}
+
+	conf := &oauth2.Config{
+		ClientID: opts.ClientID,
+		Scopes:   defaultScopes,
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: webURL + "/login/device/code",
+			TokenURL:      webURL + "/login/oauth/access_token",
+			AuthStyle:     oauth2.AuthStyleInParams,
+		},
+	}
+
+	deviceAuth, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		return fmt.Errorf("デバイス認証の開始に失敗: %w", err)
+	}
+
+	fmt.Fprintf(out, "ブラウザで %s を開き、次のコードを入力してください: %s\n", deviceAuth.VerificationURI, deviceAuth.UserCode)
+	fmt.Fprintln(out, "認証が完了するまで待機しています...")
+
+	token, err := conf.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		return fmt.Errorf("トークンの取得に失敗: %w", err)
+	}
+
+	// 表示用にユーザー名を取得（失敗してもトークンは保存する）
+	user := ""
+	client := github.NewClient(conf.Client(ctx, token))
+	if err := ghclient.SetBaseURL(client, apiURL); err != nil {
+		return err
+	}
+	if u, _, err := client.Users.Get(ctx, ""); err == nil {
+		user = u.GetLogin()
+	}
+
+	store, err := credentials.LoadStore()
+	if err != nil {
+		return err
+	}
+	store.Hosts[host] = credentials.HostCredential{Token: token.AccessToken, User: user}
+	if err := store.Save(); err != nil {
+		return err
+	}
+
+	path, _ := credentials.StorePath()
+	res.Auth = &output.Auth{Host: host, User: user, CredentialsFile: path}
+	if user != "" {
+		fmt.Fprintf(out, "%s に %s としてログインしました（認証情報: %s）\n", host, user, path)
+	} else {
+		fmt.Fprintf(out, "%s にログインしました（認証情報: %s）\n", host, path)
+	}
+	return nil
+}
+
+// Logout は認証情報ファイルからホストのトークンを削除する
+func Logout(host string, out io.Writer) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(logout(host, out, res))
+}
+
+func logout(host string, out io.Writer, res *output.Result) error {
+	host = hostOrDefault(host)
+	if out == nil {
+		out = os.Stdout
+	}
+
+	store, err := credentials.LoadStore()
+	if err != nil {
+		return err
+	}
+	if _, ok := store.Hosts[host]; !ok {
+		return fmt.Errorf("%s にはログインしていません", host)
+	}
+
+	delete(store.Hosts, host)
+	if err := store.Save(); err != nil {
+		return err
+	}
+	path, _ := credentials.StorePath()
+	res.Auth = &output.Auth{Host: host, CredentialsFile: path}
+
+	fmt.Fprintf(out, "%s からログアウトしました\n", host)
+	return nil
+}
+
+// Status はホストで使われる認証情報の取得元と、ログイン済みのホストを表示する
+// tokenSource は設定（設定ファイル、環境変数 RULEFORGE_*、フラグ）で指定した認証情報の取得元で、
+// 実行時にはほかの認証情報より優先されるため、空の場合だけ環境変数や gh CLI などの認証情報を探す
+// トークン自体は表示しない
+func Status(host, tokenSource string, out io.Writer) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(status(host, tokenSource, out, res))
+}
+
+func status(host, tokenSource string, out io.Writer, res *output.Result) error {
+	host = hostOrDefault(host)
+	if out == nil {
+		out = os.Stdout
+	}
+
+	from := tokenSource
+	if from == "" {
+		_, from = credentials.Lookup(host)
+	}
+	res.Auth = &output.Auth{Host: host, TokenSource: from}
+	if from != "" {
+		fmt.Fprintf(out, "%s: %s のトークンを使用します\n", host, from)
+	} else {
+		fmt.Fprintf(out, "%s: トークンが見つかりません。ruleforge login でログインしてください\n", host)
+	}
+
+	store, err := credentials.LoadStore()
+	if err != nil {
+		return err
+	}
+	if len(store.Hosts) == 0 {
+		return nil
+	}
+
+	path, _ := credentials.StorePath()
+	res.Auth.CredentialsFile = path
+	fmt.Fprintf(out, "\nログイン済みのホスト（%s）:\n", path)
+	hosts := make([]string, 0, len(store.Hosts))
+	for h := range store.Hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	for _, h := range hosts {
+		user := store.Hosts[h].User
+		res.Auth.LoggedIn = append(res.Auth.LoggedIn, output.Login{Host: h, User: user})
+		if user == "" {
+			user = "不明なユーザー"
+		}
+		fmt.Fprintf(out, "  %s: %s\n", h, user)
+	}
+	return nil
+}
+
+func hostOrDefault(host string) string {
+	if host == "" {
+		return credentials.DefaultHost
+	}
+	return host
+}
+
+// defaultAPIURL はホストのGitHub APIのURLを返す
+func defaultAPIURL(host string) string {
+	if host == credentials.DefaultHost {
+		return "https://api.github.com/"
+	}
+	return "https://" + host + "/api/v3/"
+}
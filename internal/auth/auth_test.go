@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/credentials"
+)
+
+func TestLoginLogout(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RULEFORGE_CONFIG_DIR", dir)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GH_CONFIG_DIR", filepath.Join(dir, "gh"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/device/code":
+			if r.FormValue("client_id") != "client-123" || r.FormValue("scope") != "repo read:org" {
+				t.Errorf("デバイスコードの要求が不正です: %v", r.Form)
+			}
+			_, _ = w.Write([]byte(`{"device_code": "dev-1", "user_code": "ABCD-1234", "verification_uri": "https://github.com/login/device", "interval": 1, "expires_in": 60}`))
+		case "/login/oauth/access_token":
+			if r.FormValue("device_code") != "dev-1" {
+				t.Errorf("デバイスコードが送信されていません: %v", r.Form)
+			}
+			_, _ = w.Write([]byte(`{"access_token": "gho_secret", "token_type": "bearer", "scope": "repo,read:org"}`))
+		case "/api/user":
+			if r.Header.Get("Authorization") != "Bearer gho_secret" {
+				t.Errorf("取得したトークンが使われていません: %s", r.Header.Get("Authorization"))
+			}
+			_, _ = w.Write([]byte(`{"login": "octocat"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	res, err := Login(context.Background(), LoginOptions{
+		Host:     "github.com",
+		ClientID: "client-123",
+		Out:      &out,
+		webURL:   server.URL,
+		apiURL:   server.URL + "/api/",
+	})
+	if err != nil {
+		t.Fatalf("ログインに失敗: %v", err)
+	}
+	if !strings.Contains(out.String(), "ABCD-1234") || !strings.Contains(out.String(), "octocat") {
+		t.Errorf("コードまたはユーザー名が表示されていません:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "gho_secret") {
+		t.Errorf("トークンが表示されました")
+	}
+	if res.Auth == nil || res.Auth.Host != "github.com" || res.Auth.User != "octocat" {
+		t.Errorf("ログインの結果が一致しません: %+v", res.Auth)
+	}
+
+	// 認証情報ファイルは所有者のみ読み書きできる
+	path := filepath.Join(dir, "credentials.yml")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("認証情報ファイルがありません: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("認証情報ファイルの権限: 期待値 0600, 実際の値 %o", perm)
+	}
+
+	// 設定の読み込み時に保存したトークンが使われる
+	if token, from := credentials.Lookup("github.com"); token != "gho_secret" || from != "ruleforge の認証情報ファイル" {
+		t.Errorf("保存したトークンが見つかりません: %s", from)
+	}
+
+	out.Reset()
+	res, err = Status("github.com", "", &out)
+	if err != nil {
+		t.Fatalf("状態の表示に失敗: %v", err)
+	}
+	if !strings.Contains(out.String(), "ruleforge の認証情報ファイル") || !strings.Contains(out.String(), "github.com: octocat") {
+		t.Errorf("状態の表示が一致しません:\n%s", out.String())
+	}
+	if res.Auth.TokenSource != "ruleforge の認証情報ファイル" || len(res.Auth.LoggedIn) != 1 || res.Auth.LoggedIn[0].User != "octocat" {
+		t.Errorf("状態の結果が一致しません: %+v", res.Auth)
+	}
+
+	// 設定で指定したトークンは実行時に優先されるため、その取得元を表示する
+	res, err = Status("github.com", "環境変数 RULEFORGE_AUTH_GITHUB_TOKEN", &bytes.Buffer{})
+	if err != nil || res.Auth.TokenSource != "環境変数 RULEFORGE_AUTH_GITHUB_TOKEN" {
+		t.Errorf("設定のトークンの取得元が表示されていません: %+v %v", res.Auth, err)
+	}
+
+	if _, err := Logout("github.com", &out); err != nil {
+		t.Fatalf("ログアウトに失敗: %v", err)
+	}
+	if token, _ := credentials.Lookup("github.com"); token != "" {
+		t.Errorf("ログアウト後もトークンが残っています")
+	}
+	if _, err := Logout("github.com", &out); err == nil {
+		t.Errorf("ログインしていないホストのログアウトでエラーになりませんでした")
+	}
+}
+
+func TestLoginRequiresClientID(t *testing.T) {
+	if _, err := Login(context.Background(), LoginOptions{}); err == nil || !strings.Contains(err.Error(), "クライアントID") {
+		t.Errorf("クライアントIDがない場合のエラーが期待されました: %v", err)
+	}
+}
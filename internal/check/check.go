@@ -0,0 +1,277 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/status"
+)
+
+// 比較の対象
+const (
+	// ベースリポジトリの現在のルール
+	AgainstBase = "base"
+	// ロックファイルの記録（ベースリポジトリにアクセスしない）
+	AgainstLock = "lock"
+)
+
+// ErrDrift はルールファイルが比較の対象と一致しない場合のエラー
+var ErrDrift = errors.New("ルールファイルが一致しません")
+
+// Options は check のオプション
+type Options struct {
+	// 比較の対象（base または lock）
+	Against string
+
+	// GitHub Actions のワークフローコマンドで注釈を出力する
+	GitHubActions bool
+
+	// Markdown のまとめを追記するファイル（GitHub Actions の GITHUB_STEP_SUMMARY）
+	StepSummary string
+
+	// 結果の出力先
+	Out io.Writer
+}
+
+// Result は1ファイル分の確認結果
+type Result struct {
+	// リポジトリのルートからの / 区切りのパス（注釈の file に使う）
+	Path string
+
+	// 比較したベースリポジトリ上のパス
+	RemotePath string
+
+	// drift の状態（up-to-date / drifted / missing）
+	State string
+}
+
+// Execute は環境変数から GitHub Actions を検出して Run を実行する
+func Execute(ctx context.Context, projects []*config.Project, opts Options) ([]Result, error) {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		opts.GitHubActions = true
+		opts.StepSummary = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+	return Run(ctx, projects, opts)
+}
+
+// Run はすべてのプロジェクトのルールファイルを比較の対象と照合する
+// download と異なりローカルのファイルは変更せず、一致しないファイルがあれば ErrDrift を返す
+func Run(ctx context.Context, projects []*config.Project, opts Options) ([]Result, error) {
+	var results []Result
+	for _, p := range projects {
+		var rs []Result
+		var err error
+		switch opts.Against {
+		case AgainstBase, "":
+			rs, err = compareBase(ctx, p.Config)
+		case AgainstLock:
+			rs, err = compareLock(p.Config)
+		default:
+			return nil, fmt.Errorf("比較の対象 %q は不明です（base または lock を指定してください）", opts.Against)
+		}
+		if err != nil {
+			if len(projects) > 1 {
+				return nil, fmt.Errorf("%s: %w", p.Path, err)
+			}
+			return nil, err
+		}
+		results = append(results, rs...)
+	}
+
+	if err := report(results, opts); err != nil {
+		return nil, err
+	}
+
+	var failed int
+	for _, r := range results {
+		if r.State != drift.StateUpToDate {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%w: %d/%d 件", ErrDrift, failed, len(results))
+	}
+	return results, nil
+}
+
+// compareBase はベースリポジトリの現在のルールと比較する
+func compareBase(ctx context.Context, cfg *config.Config) ([]Result, error) {
+	statuses, err := status.Execute(ctx, cfg, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(statuses))
+	for _, s := range statuses {
+		results = append(results, Result{Path: localPath(cfg, s.Path), RemotePath: s.RemotePath, State: s.State})
+	}
+	return results, nil
+}
+
+// compareLock はロックファイルの記録と比較する
+// ロックファイルに記録があるのにローカルにないファイルは missing とし、ロックファイル自体がない場合はエラーとする
+func compareLock(cfg *config.Config) ([]Result, error) {
+	lockPath := lockfile.Path(cfg)
+	if _, err := os.Stat(lockPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ロックファイル '%s' がありません。ruleforge download で作成してください", lockPath)
+	}
+	lock, err := lockfile.Load(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	diverged, err := lock.Diverged(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	isDiverged := map[string]bool{}
+	for _, f := range diverged {
+		isDiverged[f] = true
+	}
+
+	var results []Result
+	for _, filePath := range cfg.Files {
+		r := Result{Path: localPath(cfg, filePath), State: drift.StateUpToDate}
+		entry := lock.Files[filePath]
+		switch {
+		case isDiverged[filePath]:
+			r.State = drift.StateDrifted
+		case entry == nil:
+			// ローカルにもロックファイルにもないファイルは確認しない
+			continue
+		default:
+			if _, err := os.Stat(filepath.Join(cfg.LocalDir, filePath)); errors.Is(err, fs.ErrNotExist) {
+				r.State = drift.StateMissing
+			}
+		}
+		if entry != nil {
+			r.RemotePath = entry.RemotePath
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Record は確認結果を res に記録する
+func Record(res *output.Result, results []Result) {
+	for _, r := range results {
+		res.Files = append(res.Files, output.File{Path: r.Path, RemotePath: r.RemotePath, Status: r.State})
+	}
+}
+
+// localPath は注釈に使うカレントディレクトリ（CI ではリポジトリのルート）からの / 区切りのパスを返す
+func localPath(cfg *config.Config, filePath string) string {
+	p := filepath.Join(cfg.LocalDir, filePath)
+	if filepath.IsAbs(p) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(rel, "..") {
+				p = rel
+			}
+		}
+	}
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// message は一致しないファイルの説明を返す
+func message(r Result, against string) string {
+	switch {
+	case against == AgainstLock && r.State == drift.StateMissing:
+		return "ロックファイルに記録されたルールファイルがローカルにありません。ruleforge download で取得してください"
+	case against == AgainstLock:
+		return "ロックファイルの記録と内容が異なります。ruleforge upload でアップロードするか、ruleforge download で元に戻してください"
+	case r.State == drift.StateMissing:
+		return fmt.Sprintf("ベースリポジトリの %s がローカルにありません。ruleforge download で取得してください", r.RemotePath)
+	default:
+		return fmt.Sprintf("ベースリポジトリの %s と内容が異なります。ruleforge download で更新するか、ruleforge upload で変更をアップロードしてください", r.RemotePath)
+	}
+}
+
+// report は結果の表と、GitHub Actions の場合は注釈とまとめを出力する
+func report(results []Result, opts Options) error {
+	tw := tabwriter.NewWriter(opts.Out, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.State, r.Path, r.RemotePath)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if !opts.GitHubActions {
+		return nil
+	}
+
+	for _, r := range results {
+		if r.State != drift.StateUpToDate {
+			fmt.Fprintf(opts.Out, "::error file=%s,title=%s::%s\n",
+				escapeProperty(r.Path), escapeProperty("ruleforge check"), escapeData(message(r, opts.Against)))
+		}
+	}
+
+	if opts.StepSummary == "" {
+		return nil
+	}
+	f, err := os.OpenFile(opts.StepSummary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ステップのまとめ '%s' を開けません: %w", opts.StepSummary, err)
+	}
+	if _, err := io.WriteString(f, summaryMarkdown(results, opts.Against)); err != nil {
+		f.Close()
+		return fmt.Errorf("ステップのまとめの書き込みに失敗: %w", err)
+	}
+	return f.Close()
+}
+
+// summaryMarkdown はステップのまとめに書き込む Markdown を生成する
+func summaryMarkdown(results []Result, against string) string {
+	var b strings.Builder
+	b.WriteString("## ruleforge check\n\n")
+
+	summary := status.Summary{}
+	var failed []Result
+	for _, r := range results {
+		summary[r.State]++
+		if r.State != drift.StateUpToDate {
+			failed = append(failed, r)
+		}
+	}
+
+	if len(failed) == 0 {
+		fmt.Fprintf(&b, "すべてのルールファイル（%d 件）が一致しています。\n\n", len(results))
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%s\n\n", summary)
+	b.WriteString("| 状態 | ファイル | 比較したパス | 対処 |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, r := range failed {
+		remotePath := "-"
+		if r.RemotePath != "" {
+			remotePath = "`" + r.RemotePath + "`"
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n", r.State, r.Path, remotePath, strings.ReplaceAll(message(r, against), "|", `\|`))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// escapeData はワークフローコマンドのメッセージをエスケープする
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty はワークフローコマンドのプロパティの値をエスケープする
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,161 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// newContentsServer はパスごとのファイル内容を返すモックサーバーを作成し、API の URL を返す
+func newContentsServer(t *testing.T, files map[string]string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		content, ok := files[strings.TrimPrefix(r.URL.Path, "/repos/org/rules/contents/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/"
+}
+
+// writeFiles はディレクトリにファイルを作成する
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunAgainstBase(t *testing.T) {
+	apiURL := newContentsServer(t, map[string]string{
+		"AGENTS.md":          "agents",
+		"app/CLAUDE.md":      "claude",
+		"app/.windsurfrules": "windsurf",
+	})
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"AGENTS.md": "agents", "CLAUDE.md": "edited, 100%"})
+	cfg := &config.Config{
+		BaseRepo: "org/rules",
+		RepoName: "app",
+		LocalDir: dir,
+		Files:    []string{"AGENTS.md", "CLAUDE.md", ".windsurfrules"},
+		Auth:     config.AuthConfig{APIURL: apiURL},
+	}
+	summaryFile := filepath.Join(t.TempDir(), "summary.md")
+
+	var out bytes.Buffer
+	results, err := Run(context.Background(), []*config.Project{{Path: ".", Config: cfg}}, Options{
+		Against:       AgainstBase,
+		GitHubActions: true,
+		StepSummary:   summaryFile,
+		Out:           &out,
+	})
+	if !errors.Is(err, ErrDrift) || !strings.Contains(err.Error(), "2/3") {
+		t.Fatalf("ErrDrift が期待されました: %v", err)
+	}
+
+	states := map[string]string{}
+	for _, r := range results {
+		states[filepath.Base(r.Path)] = r.State
+	}
+	if states["AGENTS.md"] != drift.StateUpToDate || states["CLAUDE.md"] != drift.StateDrifted || states[".windsurfrules"] != drift.StateMissing {
+		t.Errorf("ファイルごとの状態が期待と異なります: %v", states)
+	}
+	res := output.NewResult("")
+	Record(res, results)
+	if len(res.Files) != 3 || res.Files[1].Status != drift.StateDrifted || res.Files[1].RemotePath != "app/CLAUDE.md" {
+		t.Errorf("記録した結果が期待と異なります: %+v", res.Files)
+	}
+
+	// 一致しないファイルだけに注釈を出力する
+	claude := escapeProperty(filepath.ToSlash(filepath.Join(dir, "CLAUDE.md")))
+	for _, want := range []string{
+		"::error file=" + claude + ",title=ruleforge check::ベースリポジトリの app/CLAUDE.md と内容が異なります",
+		"ベースリポジトリの app/.windsurfrules がローカルにありません",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "::error file="+escapeProperty(filepath.ToSlash(filepath.Join(dir, "AGENTS.md")))) {
+		t.Errorf("一致しているファイルに注釈が出力されました:\n%s", out.String())
+	}
+
+	summary, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatalf("ステップのまとめが書き込まれていません: %v", err)
+	}
+	for _, want := range []string{"## ruleforge check", "最新: 1 / 差分あり: 1 / ローカルになし: 1", "| drifted | `" + filepath.ToSlash(filepath.Join(dir, "CLAUDE.md")) + "` | `app/CLAUDE.md` |"} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("まとめに %q が含まれていません:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRunAgainstLock(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{LocalDir: dir, Files: []string{"AGENTS.md", "CLAUDE.md"}}
+	project := []*config.Project{{Path: ".", Config: cfg}}
+
+	// ロックファイルがなければエラー
+	if _, err := Run(context.Background(), project, Options{Against: AgainstLock, Out: &bytes.Buffer{}}); err == nil || errors.Is(err, ErrDrift) {
+		t.Fatalf("ロックファイルがないエラーが期待されました: %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"AGENTS.md": "agents"})
+	err := lockfile.Update(lockfile.Path(cfg), "org/rules", func(lock *lockfile.Lockfile) {
+		lock.Set("AGENTS.md", "AGENTS.md", []byte("agents"), lockfile.SourceDownload)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ローカルにもロックファイルにもないファイルは確認しない
+	var out bytes.Buffer
+	results, err := Run(context.Background(), project, Options{Against: AgainstLock, Out: &out})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("ロックファイルと一致しているのに失敗しました: %v, %+v", err, results)
+	}
+	if strings.Contains(out.String(), "::error") {
+		t.Errorf("GitHub Actions 以外で注釈が出力されました:\n%s", out.String())
+	}
+
+	writeFiles(t, dir, map[string]string{"AGENTS.md": "edited", "CLAUDE.md": "new"})
+	if _, err := Run(context.Background(), project, Options{Against: AgainstLock, Out: &bytes.Buffer{}}); !errors.Is(err, ErrDrift) || !strings.Contains(err.Error(), "2/2") {
+		t.Errorf("ErrDrift が期待されました: %v", err)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := escapeData("100%\nfile: a,b"), "100%25%0Afile: a,b"; got != want {
+		t.Errorf("escapeData: 期待値 %q, 実際の値 %q", want, got)
+	}
+	if got, want := escapeProperty("a,b:c"), "a%2Cb%3Ac"; got != want {
+		t.Errorf("escapeProperty: 期待値 %q, 実際の値 %q", want, got)
+	}
+}
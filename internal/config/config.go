@@ -3,22 +3,44 @@ package config
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
+	"time"
 
+	"github.com/hiroyannnn/ruleforge/internal/credentials"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
 	"gopkg.in/yaml.v3"
 )
 
 // Config はアプリケーション全体の設定
 type Config struct {
+	// 設定ファイルの形式のバージョン（古い形式は config migrate で更新できる）
+	Version int `yaml:"version"`
+
 	// ベースリポジトリのURL
 	BaseRepo string `yaml:"base-repo"`
 
 	// 対象ファイルのリスト
 	Files []string `yaml:"target-files"`
 
-	// GitHubトークン（環境変数からの読み込みも可）
-	GitHubToken string `yaml:"github-token"`
+	// GitHub API の認証設定
+	Auth AuthConfig `yaml:"auth"`
+
+	// GitHubトークンの取得元（ログ表示用、設定ファイルには書き込まない）
+	TokenSource string `yaml:"-"`
+
+	// 環境変数や gh CLI などの認証情報を探したかどうか（ResolveToken 用）
+	tokenResolved bool
+
+	// 設定値ごとの取得元（キーはドット区切りの設定ファイルのキー、config show --origin 用）
+	Origins map[string]string `yaml:"-"`
+
+	// 形式が古く、読み込み時に変換した設定ファイルのパス（config migrate で更新できる）
+	Outdated []string `yaml:"-"`
+
+	// 設定ファイルで指定された値の行（path:line、問題の表示用）
+	lines map[string]string
+
+	// 読み込み中に見つかった設定ファイルの問題（不明なキーや型の誤り）
+	problems []Problem
 
 	// コミットメッセージやPRのタイトル/説明
 	Message string `yaml:"message"`
@@ -29,82 +51,276 @@ type Config struct {
 	// ローカルディレクトリパス（カレントディレクトリがデフォルト）
 	LocalDir string `yaml:"local-dir"`
 
-	// 作業用ブランチ名（アップロード用）
+	// 作業用ブランチ名（アップロード用、リポジトリごとに固定して既存のPRを再利用する）
 	BranchName string `yaml:"branch-name"`
 
 	// カレントリポジトリ名（自動検出される）
 	RepoName string `yaml:"repo-name"`
+
+	// カレントリポジトリの所有者（自動検出される、layout: owner で使用）
+	RepoOwner string `yaml:"repo-owner"`
+
+	// ベースリポジトリでのリポジトリ固有のルールの配置（flat: <repo-name>/、owner: <repo-owner>/<repo-name>/）
+	Layout string `yaml:"layout"`
+
+	// ベースリポジトリでのリポジトリ固有のルールのディレクトリ（指定した場合は repo-name と layout より優先）
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// モノレポのサブプロジェクト（ディレクトリごとの対象ファイルとベースリポジトリのディレクトリ）
+	Projects []ProjectConfig `yaml:"projects,omitempty"`
+
+	// サブプロジェクトのディレクトリ（リポジトリのルートからの / 区切りの相対パス、ルートの場合は空）
+	ProjectPath string `yaml:"-"`
+
+	// モノレポのほかのプロジェクトのベースリポジトリでのディレクトリ（sync-deletions で削除しない）
+	OtherProjectDirs []string `yaml:"-"`
+
+	// アップロード時、ローカルに存在しない（または対象外になった）ファイルをベースリポジトリから削除する
+	SyncDeletions bool `yaml:"sync-deletions,omitempty"`
+
+	// ダウンロード時の並列数
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// GitHub API呼び出しのリトライ設定
+	Retry RetryConfig `yaml:"retry,omitempty"`
+
+	// 作成するプルリクエストに設定するラベルやレビュアー
+	PullRequest PullRequestConfig `yaml:"pull-request,omitempty"`
+
+	// ルールを配布する利用側リポジトリの設定（fleet sync 用）
+	Fleet FleetConfig `yaml:"fleet,omitempty"`
 }
 
-// Load は設定ファイルとデフォルト値から設定を読み込む
-func Load(configFile string) (*Config, error) {
+// AuthConfig は GitHub API の認証設定
+type AuthConfig struct {
+	// GitHubトークン（環境変数からの読み込みも可）
+	GitHubToken string `yaml:"github-token"`
+
+	// GitHub App 認証の設定（github-token の代わりにインストールトークンを使用）
+	App AppConfig `yaml:"app,omitempty"`
+
+	// GitHub APIのURL（GitHub Enterprise Server 用、デフォルトは https://api.github.com/）
+	APIURL string `yaml:"api-url,omitempty"`
+
+	// ruleforge login で使う OAuth App のクライアントID
+	OAuthClientID string `yaml:"oauth-client-id,omitempty"`
+}
+
+// AppConfig は GitHub App として認証するための設定
+type AppConfig struct {
+	// GitHub App の App ID
+	AppID int64 `yaml:"app-id,omitempty"`
+
+	// 対象の組織またはユーザーへのインストールID
+	InstallationID int64 `yaml:"installation-id,omitempty"`
+
+	// GitHub App の秘密鍵（PEM形式）のファイルパス
+	PrivateKeyFile string `yaml:"private-key-file,omitempty"`
+}
+
+// Enabled は GitHub App の設定がされているかどうかを返す
+func (a AppConfig) Enabled() bool {
+	return a.AppID != 0
+}
+
+// HasCredentials は GitHub API の認証情報（トークンまたは GitHub App）が設定されているかどうかを返す
+// トークンが設定されていない場合は、先に ResolveToken で認証情報を探す
+func (c *Config) HasCredentials() bool {
+	c.ResolveToken()
+	return c.Auth.GitHubToken != "" || c.Auth.App.Enabled()
+}
+
+// ResolveToken は GitHub トークンが設定されていない場合に、環境変数や gh CLI などの認証情報を順に探す
+// git credential の実行や gh CLI の設定ファイルの読み込みを伴うため、設定の読み込み時ではなく
+// GitHub API を使う直前に呼び出す（2回目以降は何もしない）
+// GitHub App を使う場合はトークンを探さない
+func (c *Config) ResolveToken() {
+	if c.tokenResolved {
+		return
+	}
+	c.tokenResolved = true
+	if c.Auth.GitHubToken != "" || c.Auth.App.Enabled() {
+		return
+	}
+
+	c.Auth.GitHubToken, c.TokenSource = credentials.Lookup(credentials.HostFromRepoURL(c.BaseRepo))
+	if c.Auth.GitHubToken != "" && c.Origins != nil {
+		c.Origins[tokenKey] = c.TokenSource
+	}
+}
+
+// RetryConfig はレート制限や一時的なエラー発生時のリトライ設定
+type RetryConfig struct {
+	// 最大リトライ回数（0でリトライしない）
+	MaxRetries int `yaml:"max-retries"`
+
+	// 指数バックオフの初期待機時間
+	BaseDelay time.Duration `yaml:"base-delay"`
+
+	// 1回あたりの最大待機時間（これを超える待機が必要な場合はリトライしない）
+	MaxWait time.Duration `yaml:"max-wait"`
+}
+
+// PullRequestConfig はプルリクエストに設定する追加情報
+type PullRequestConfig struct {
+	// 付与するラベル
+	Labels []string `yaml:"labels,omitempty"`
+
+	// レビューを依頼するユーザー
+	Reviewers []string `yaml:"reviewers,omitempty"`
+
+	// レビューを依頼するチーム（組織内のチームのslug）
+	TeamReviewers []string `yaml:"team-reviewers,omitempty"`
+
+	// アサインするユーザー
+	Assignees []string `yaml:"assignees,omitempty"`
+
+	// ドラフトとして作成する
+	Draft bool `yaml:"draft,omitempty"`
+
+	// 設定するマイルストーン（タイトルまたは番号）
+	Milestone string `yaml:"milestone,omitempty"`
+}
+
+// ProjectConfig はモノレポのサブプロジェクトの設定
+type ProjectConfig struct {
+	// サブプロジェクトのディレクトリ（リポジトリのルートからの相対パス）
+	Path string `yaml:"path"`
+
+	// 対象ファイル（サブプロジェクトのディレクトリからの相対パス、省略時はルートの target-files）
+	Files []string `yaml:"target-files,omitempty"`
+
+	// ベースリポジトリのディレクトリ（省略時はリポジトリのディレクトリ配下の <path>）
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// FleetConfig はベースリポジトリのルールを配布する利用側リポジトリの設定
+type FleetConfig struct {
+	// 配布先リポジトリのリスト（owner/repo 形式またはURL）
+	Repos []string `yaml:"repos"`
+
+	// 配布用のブランチ名
+	BranchName string `yaml:"branch-name,omitempty"`
+
+	// 中断した同期を再開するための状態ファイル
+	StateFile string `yaml:"state-file,omitempty"`
+}
+
+// Load はデフォルト値、ユーザー設定ファイル、設定ファイル、RULEFORGE_* の環境変数、overrides の順に
+// 後のものを優先して設定を読み込む
+func Load(configFile string, overrides ...Override) (*Config, error) {
+	var files []fileLayer
+	if configFile != "" {
+		files = append(files, fileLayer{path: configFile, label: originRepoFile})
+	}
+	return load(files, overrides)
+}
+
+// fileLayer は設定を読み込むファイルと取得元の表示名
+type fileLayer struct {
+	path  string
+	label string
+}
+
+// load はデフォルト値とユーザー設定ファイルに、files、環境変数、overrides の順に重ねて設定を読み込む
+func load(files []fileLayer, overrides []Override) (*Config, error) {
 	// デフォルト設定
 	cfg := &Config{
-		Files:      []string{".cursor/rules.md"},
-		LocalDir:   ".",
-		BranchName: fmt.Sprintf("update-agent-rules-%d", os.Getpid()),
+		Version:     CurrentVersion,
+		Files:       []string{".cursor/rules.md"},
+		LocalDir:    ".",
+		BranchName:  "update-agent-rules",
+		Layout:      LayoutFlat,
+		Concurrency: 4,
+		Retry: RetryConfig{
+			MaxRetries: 3,
+			BaseDelay:  time.Second,
+			MaxWait:    2 * time.Minute,
+		},
+		Fleet: FleetConfig{
+			BranchName: "ruleforge/sync-rules",
+			StateFile:  ".ruleforge-fleet-state.json",
+		},
+		Origins: map[string]string{},
+		lines:   map[string]string{},
 	}
 
-	// 設定ファイルが存在する場合は読み込む
-	if configFile != "" {
-		if _, err := os.Stat(configFile); err == nil {
-			f, err := os.Open(configFile)
-			if err != nil {
-				return nil, fmt.Errorf("設定ファイルを開けません: %w", err)
-			}
-			defer f.Close()
+	// ユーザー設定ファイル（トークンや全リポジトリ共通のデフォルト値）
+	if userFile, err := UserConfigPath(); err == nil {
+		if err := cfg.applyFile(userFile, originUserFile); err != nil {
+			return nil, err
+		}
+	}
 
-			decoder := yaml.NewDecoder(f)
-			if err := decoder.Decode(cfg); err != nil {
-				return nil, fmt.Errorf("設定ファイルの解析に失敗: %w", err)
-			}
+	// リポジトリの設定ファイル（サブプロジェクトの場合はその設定ファイルも重ねる）
+	for _, f := range files {
+		if err := cfg.applyFile(f.path, f.label); err != nil {
+			return nil, err
 		}
 	}
 
-	// 環境変数から GitHub トークンを設定（設定ファイル内で ${GITHUB_TOKEN} の形式で指定されている場合）
-	if strings.HasPrefix(cfg.GitHubToken, "${") && strings.HasSuffix(cfg.GitHubToken, "}") {
-		envName := strings.TrimSuffix(strings.TrimPrefix(cfg.GitHubToken, "${"), "}")
-		cfg.GitHubToken = os.Getenv(envName)
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	for _, o := range overrides {
+		if err := cfg.apply(o); err != nil {
+			return nil, err
+		}
 	}
 
-	// GitHub トークンが設定されていない場合は環境変数から直接取得
-	if cfg.GitHubToken == "" {
-		cfg.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	// 環境変数や gh CLI などの認証情報は、API を使うときに ResolveToken で探す
+	if cfg.Auth.GitHubToken == "" {
+		cfg.TokenSource = ""
 	}
 
-	// カレントリポジトリ名の取得を試みる（git remoteから）
-	if cfg.RepoName == "" {
-		repoName, err := detectRepoName()
-		if err == nil {
-			cfg.RepoName = repoName
+	// カレントリポジトリの所有者と名前の取得を試みる（git remoteから）
+	if cfg.RepoName == "" || cfg.RepoOwner == "" {
+		if remote, err := gitutil.DetectRemote("."); err == nil {
+			if cfg.RepoName == "" {
+				cfg.RepoName = remote.Repo
+				cfg.Origins["repo-name"] = originDetected
+			}
+			if cfg.RepoOwner == "" {
+				cfg.RepoOwner = remote.Owner
+				cfg.Origins["repo-owner"] = originDetected
+			}
 		}
 	}
 
+	// 不明なキーや不正な値はまとめて報告する
+	if problems := append(cfg.problems, cfg.Validate()...); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
 	return cfg, nil
 }
 
 // GenerateConfigFile は設定ファイルテンプレートをカレントディレクトリに生成する
-func GenerateConfigFile(outputFile string, baseRepo string, files []string) error {
+// force が false の場合、既存のファイルは上書きしない
+func GenerateConfigFile(outputFile string, baseRepo string, files []string, force bool) error {
 	// ファイルが既に存在する場合は確認
-	if _, err := os.Stat(outputFile); err == nil {
-		return fmt.Errorf("設定ファイル %s は既に存在します。上書きするには先に削除してください", outputFile)
+	if _, err := os.Stat(outputFile); err == nil && !force {
+		return fmt.Errorf("設定ファイル %s は既に存在します。上書きするには --force を指定してください", outputFile)
 	}
 
 	// デフォルト設定
 	cfg := &Config{
-		BaseRepo:    baseRepo,
-		Files:       files,
-		GitHubToken: "${GITHUB_TOKEN}",
-		Message:     "Update Cursor Rules",
-		LocalDir:    ".",
-		BranchName:  "update-agent-rules",
-		Verbose:     false,
+		BaseRepo:   baseRepo,
+		Files:      files,
+		Version:    CurrentVersion,
+		Auth:       AuthConfig{GitHubToken: "${GITHUB_TOKEN}"},
+		Message:    "Update Cursor Rules",
+		LocalDir:   ".",
+		BranchName: "update-agent-rules",
+		Layout:     LayoutFlat,
+		Verbose:    false,
 	}
 
-	// リポジトリ名の自動検出を試みる
-	repoName, err := detectRepoName()
-	if err == nil {
-		cfg.RepoName = repoName
+	// リポジトリの所有者と名前の自動検出を試みる
+	if remote, err := gitutil.DetectRemote("."); err == nil {
+		cfg.RepoName = remote.Repo
+		cfg.RepoOwner = remote.Owner
 	}
 
 	// 設定ファイルにコメントを追加するため、マーシャルした結果を文字列として取得
@@ -124,43 +340,5 @@ func GenerateConfigFile(outputFile string, baseRepo string, files []string) erro
 	if err := os.WriteFile(outputFile, []byte(configContent), 0644); err != nil {
 		return fmt.Errorf("設定ファイルの書き込みに失敗: %w", err)
 	}
-
-	fmt.Printf("設定ファイル %s を生成しました\n", outputFile)
 	return nil
 }
-
-// detectRepoName はカレントディレクトリのGitリポジトリからリポジトリ名を検出
-func detectRepoName() (string, error) {
-	// .git/config ファイルのパスを作成
-	gitDir := filepath.Join(".", ".git")
-	gitConfig := filepath.Join(gitDir, "config")
-
-	// .git/config ファイルを読み込み
-	data, err := os.ReadFile(gitConfig)
-	if err != nil {
-		return "", fmt.Errorf("Gitリポジトリが見つかりません: %w", err)
-	}
-
-	// URL行を探してリポジトリ名を抽出
-	configStr := string(data)
-	lines := strings.Split(configStr, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "url = ") {
-			url := strings.TrimPrefix(line, "url = ")
-
-			// github.com/username/repo.git 形式からrepo名を抽出
-			if strings.Contains(url, "github.com") {
-				parts := strings.Split(url, "/")
-				if len(parts) > 1 {
-					repoName := parts[len(parts)-1]
-					repoName = strings.TrimSuffix(repoName, ".git")
-					return repoName, nil
-				}
-			}
-		}
-	}
-
-	return "", fmt.Errorf("リモートリポジトリ名を検出できませんでした")
-}
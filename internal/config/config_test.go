@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -54,7 +55,7 @@ repo-name: "test-repo"
 		{"BaseRepo", cfg.BaseRepo, "https://github.com/test/repo"},
 		{"Files[0]", cfg.Files[0], ".cursor/rules.md"},
 		{"Files[1]", cfg.Files[1], ".cursor/config.json"},
-		{"GitHubToken", cfg.GitHubToken, "test-token-value"},
+		{"GitHubToken", cfg.Auth.GitHubToken, "test-token-value"},
 		{"Message", cfg.Message, "Test message"},
 		{"Verbose", cfg.Verbose, true},
 		{"LocalDir", cfg.LocalDir, "./test-dir"},
@@ -87,8 +88,9 @@ func TestLoadDefaults(t *testing.T) {
 		t.Errorf("LocalDir のデフォルト値が正しくありません: %v", cfg.LocalDir)
 	}
 
-	if cfg.BranchName == "" {
-		t.Errorf("BranchName のデフォルト値が設定されていません")
+	// 実行ごとに異なるブランチにならないよう、デフォルト値は固定
+	if cfg.BranchName != "update-agent-rules" {
+		t.Errorf("BranchName のデフォルト値が正しくありません: %v", cfg.BranchName)
 	}
 }
 
@@ -157,3 +159,33 @@ func TestParseRepoURL(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadRetryConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	testConfigPath := filepath.Join(tempDir, ".ruleforge.yaml")
+	testConfigContent := `
+base-repo: https://github.com/test/repo
+retry:
+  max-retries: 5
+  max-wait: 30s
+`
+	if err := os.WriteFile(testConfigPath, []byte(testConfigContent), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	cfg, err := Load(testConfigPath)
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+
+	if cfg.Retry.MaxRetries != 5 {
+		t.Errorf("Retry.MaxRetries: 期待値 %d, 実際の値 %d", 5, cfg.Retry.MaxRetries)
+	}
+	if cfg.Retry.MaxWait != 30*time.Second {
+		t.Errorf("Retry.MaxWait: 期待値 %v, 実際の値 %v", 30*time.Second, cfg.Retry.MaxWait)
+	}
+	// 指定していない項目はデフォルト値が維持される
+	if cfg.Retry.BaseDelay != time.Second {
+		t.Errorf("Retry.BaseDelay: 期待値 %v, 実際の値 %v", time.Second, cfg.Retry.BaseDelay)
+	}
+}
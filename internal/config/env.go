@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tokenKey は未定義の環境変数を許容するキー（未定義の場合は他の取得元からトークンを探す）
+const tokenKey = "auth.github-token"
+
+// envExpander は設定ファイルの値に含まれる環境変数の参照を展開する
+type envExpander struct {
+	// エラーメッセージに表示する設定ファイルのパス
+	file string
+
+	// github-token で参照された環境変数名（トークンの取得元の表示用）
+	tokenVars []string
+}
+
+// expandNode はYAMLのノードを再帰的にたどり、文字列の値とリストの要素に含まれる
+// ${VAR} と ${VAR:-default} を環境変数の値で置き換える（マッピングのキーは展開しない）
+func (e *envExpander) expandNode(node *yaml.Node, path string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := e.expandNode(child, path); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := e.expandNode(node.Content[i+1], key); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := e.expandNode(child, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		value, refs, err := expandEnv(node.Value, path == tokenKey)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s: %w", e.file, node.Line, path, err)
+		}
+		if path == tokenKey {
+			e.tokenVars = append(e.tokenVars, refs...)
+		}
+		node.Value = value
+		// 引用符のない値は展開後の値で型を判定し直す（concurrency: ${JOBS} など）
+		if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+	}
+	return nil
+}
+
+// expandEnv は文字列に含まれる ${VAR} と ${VAR:-default} を展開し、参照した環境変数名を返す
+// ${VAR:-default} は VAR が未定義または空の場合に default を使う
+// $${ は展開せずに ${ として扱う
+// allowUndefined が false の場合、未定義の環境変数を参照するとエラーになる
+func expandEnv(s string, allowUndefined bool) (string, []string, error) {
+	var b strings.Builder
+	var refs []string
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+
+		end := strings.Index(s[i:], "}")
+		if end < 0 {
+			return "", nil, fmt.Errorf("環境変数の参照 %q が閉じられていません", s[i:])
+		}
+		expr := s[i+2 : i+end]
+		b.WriteString(s[:i])
+		s = s[i+end+1:]
+
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !validEnvName(name) {
+			return "", nil, fmt.Errorf("環境変数名 %q が不正です", name)
+		}
+		refs = append(refs, name)
+
+		value, ok := os.LookupEnv(name)
+		switch {
+		case hasDefault && value == "":
+			value = def
+		case !ok && !allowUndefined:
+			return "", nil, fmt.Errorf("環境変数 %s が定義されていません（${%s:-デフォルト値} の形式でデフォルト値を指定できます）", name, name)
+		}
+		b.WriteString(value)
+	}
+
+	return b.String(), refs, nil
+}
+
+// validEnvName は環境変数名として使える文字列（英字またはアンダースコアで始まる英数字）かどうかを返す
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("RF_OWNER", "example")
+	t.Setenv("RF_EMPTY", "")
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"https://github.com/${RF_OWNER}/rules", "https://github.com/example/rules", false},
+		{"${RF_OWNER}-${RF_OWNER}", "example-example", false},
+		{"${RF_MISSING:-fallback}", "fallback", false},
+		{"${RF_EMPTY:-fallback}", "fallback", false},
+		{"${RF_EMPTY}", "", false},
+		{"$${RF_OWNER}", "${RF_OWNER}", false},
+		{"price: $5", "price: $5", false},
+		{"${RF_MISSING}", "", true},
+		{"${RF_OWNER", "", true},
+		{"${1BAD}", "", true},
+	}
+
+	for _, tt := range tests {
+		got, _, err := expandEnv(tt.input, false)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expandEnv(%q): エラーが期待されましたが成功しました: %q", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandEnv(%q): 予期しないエラー: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("expandEnv(%q) = %q, 期待値 %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv("RF_ORG", "acme")
+	t.Setenv("RF_JOBS", "8")
+	t.Setenv("RF_TOKEN", "token-value")
+
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	content := `
+base-repo: https://github.com/${RF_ORG}/rules
+target-files:
+  - .cursor/${RF_RULES_FILE:-rules.md}
+  - "${RF_ORG}.md"
+github-token: ${RF_TOKEN}
+branch-name: rules-${RF_BRANCH:-main}
+message: "Update ${RF_ORG} rules"
+concurrency: ${RF_JOBS}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		actual   interface{}
+		expected interface{}
+	}{
+		{"BaseRepo", cfg.BaseRepo, "https://github.com/acme/rules"},
+		{"Files[0]", cfg.Files[0], ".cursor/rules.md"},
+		{"Files[1]", cfg.Files[1], "acme.md"},
+		{"GitHubToken", cfg.Auth.GitHubToken, "token-value"},
+		{"TokenSource", cfg.TokenSource, "環境変数 RF_TOKEN（設定ファイルで指定）"},
+		{"BranchName", cfg.BranchName, "rules-main"},
+		{"Message", cfg.Message, "Update acme rules"},
+		{"Concurrency", cfg.Concurrency, 8},
+	}
+	for _, tc := range testCases {
+		if tc.actual != tc.expected {
+			t.Errorf("%s: 期待値 %v, 実際の値 %v", tc.name, tc.expected, tc.actual)
+		}
+	}
+}
+
+func TestLoadUndefinedEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	content := `base-repo: https://github.com/test/repo
+target-files:
+  - .cursor/rules.md
+  - ${RF_UNDEFINED_FILE}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("未定義の環境変数でエラーが期待されましたが成功しました")
+	}
+	// エラーは設定ファイルの行とキーを示す
+	for _, want := range []string{path + ":4", "target-files[1]", "RF_UNDEFINED_FILE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("エラーメッセージに %q が含まれていません: %v", want, err)
+		}
+	}
+}
+
+func TestLoadUndefinedTokenEnv(t *testing.T) {
+	// github-token が参照する環境変数が未定義でもエラーにしない（他の取得元からトークンを探す）
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	if err := os.WriteFile(path, []byte("github-token: ${RF_UNDEFINED_TOKEN}\n"), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+	if cfg.Auth.GitHubToken != "" || cfg.TokenSource != "" {
+		t.Errorf("トークンが設定されました: %q (%s)", cfg.Auth.GitHubToken, cfg.TokenSource)
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "env-token")
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	// 設定の読み込み時には環境変数や gh CLI などの認証情報を探さない
+	cfg, err := Load("", Override{Key: "base-repo", Value: "org/rules", Origin: "フラグ --base-repo"})
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+	if cfg.Auth.GitHubToken != "" || cfg.TokenSource != "" {
+		t.Errorf("読み込み時にトークンが探されました: %s", cfg.TokenSource)
+	}
+
+	// API を使う前に探す
+	if !cfg.HasCredentials() || cfg.Auth.GitHubToken != "env-token" || cfg.TokenSource != "環境変数 GITHUB_TOKEN" {
+		t.Errorf("トークンが見つかりません: %q (%s)", cfg.Auth.GitHubToken, cfg.TokenSource)
+	}
+	if got := cfg.Origins["auth.github-token"]; got != "環境変数 GITHUB_TOKEN" {
+		t.Errorf("トークンの取得元が記録されていません: %s", got)
+	}
+}
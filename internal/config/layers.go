@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/hiroyannnn/ruleforge/internal/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+// 設定値の取得元の表示名
+const (
+	originDefault  = "デフォルト値"
+	originUserFile = "ユーザー設定ファイル"
+	originRepoFile = "設定ファイル"
+	originDetected = "git remote から検出"
+)
+
+// Override はコマンドラインのフラグなど、設定ファイルと環境変数より優先する値
+type Override struct {
+	// 設定ファイルのキー（ネストしたキーは retry.max-retries のようにドットで区切る）
+	Key string
+
+	// 設定する値
+	Value any
+
+	// 取得元の表示名（例: フラグ --base-repo）
+	Origin string
+}
+
+// configKey は設定ファイルで指定できるキー
+type configKey struct {
+	path string
+	list bool
+}
+
+// UserConfigPath はユーザー設定ファイルのパスを返す
+func UserConfigPath() (string, error) {
+	dir, err := credentials.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// applyFile は設定ファイルを読み込み、指定されているキーの値で設定を上書きする
+// ファイルが存在しない場合は何もしない
+func (c *Config) applyFile(path, label string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("設定ファイルを開けません: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("設定ファイル %s の解析に失敗: %w", path, err)
+	}
+	if root.Kind == 0 {
+		return nil
+	}
+
+	// 古い形式の設定ファイルは現在の形式に変換して読み込む
+	applied, err := migrateNode(&root)
+	if err != nil {
+		return fmt.Errorf("設定ファイル %s: %w", path, err)
+	}
+	if len(applied) > 0 {
+		c.Outdated = append(c.Outdated, path)
+	}
+
+	// 環境変数の参照を展開してから構造体に読み込む
+	expander := &envExpander{file: path}
+	if err := expander.expandNode(&root, ""); err != nil {
+		return err
+	}
+	// auth.github-token の環境変数が未定義の場合は、下位の設定や他の取得元のトークンを使う
+	dropEmptyToken(&root)
+
+	// 不明なキーと型の誤りは読み込みを続けて、最後にまとめて報告する
+	c.problems = append(c.problems, checkKeys(&root, reflect.TypeOf(Config{}), "", path)...)
+	if err := root.Decode(c); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return fmt.Errorf("設定ファイル %s の解析に失敗: %w", path, err)
+		}
+		c.problems = append(c.problems, typeErrorProblems(typeErr, path)...)
+	}
+
+	walkLeaves(&root, "", func(key string, node *yaml.Node) {
+		c.Origins[key] = fmt.Sprintf("%s (%s:%d)", label, path, node.Line)
+		c.lines[key] = fmt.Sprintf("%s:%d", path, node.Line)
+		for i, item := range node.Content {
+			if node.Kind == yaml.SequenceNode {
+				c.lines[fmt.Sprintf("%s[%d]", key, i)] = fmt.Sprintf("%s:%d", path, item.Line)
+			}
+		}
+		if key == tokenKey {
+			if len(expander.tokenVars) > 0 {
+				c.TokenSource = fmt.Sprintf("環境変数 %s（設定ファイルで指定）", strings.Join(expander.tokenVars, ", "))
+			} else {
+				c.TokenSource = "設定ファイル"
+			}
+		}
+	})
+	return nil
+}
+
+// envIgnored は環境変数では指定できないキー
+// version は設定ファイルの形式を表すため、ツール自身のバージョンとして使われがちな RULEFORGE_VERSION では上書きしない
+var envIgnored = map[string]bool{"version": true}
+
+// applyEnv は RULEFORGE_<キー> の環境変数で設定を上書きする
+// キーの英小文字は大文字に、- と . は _ に置き換える（例: RULEFORGE_BASE_REPO、RULEFORGE_RETRY_MAX_RETRIES）
+// リストの値はカンマ区切りで指定する
+func (c *Config) applyEnv() error {
+	for _, key := range configKeys() {
+		if envIgnored[key.path] {
+			continue
+		}
+		name := EnvName(key.path)
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if key.list {
+			node = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+				}
+			}
+		}
+
+		if err := c.setNode(key.path, node); err != nil {
+			return fmt.Errorf("環境変数 %s の値が不正です: %w", name, err)
+		}
+		c.setOrigin(key.path, "環境変数 "+name)
+		if key.path == tokenKey {
+			c.TokenSource = "環境変数 " + name
+		}
+	}
+	return nil
+}
+
+// apply はフラグなどの値で設定を上書きする
+func (c *Config) apply(o Override) error {
+	var node yaml.Node
+	if err := node.Encode(o.Value); err != nil {
+		return fmt.Errorf("%s の値が不正です: %w", o.Origin, err)
+	}
+	if err := c.setNode(o.Key, &node); err != nil {
+		return fmt.Errorf("%s の値が不正です: %w", o.Origin, err)
+	}
+	c.setOrigin(o.Key, o.Origin)
+	if o.Key == tokenKey {
+		c.TokenSource = o.Origin
+	}
+	return nil
+}
+
+// setOrigin は設定ファイル以外で上書きされたキーの取得元を記録する
+func (c *Config) setOrigin(key, origin string) {
+	c.Origins[key] = origin
+	for k := range c.lines {
+		if k == key || strings.HasPrefix(k, key+"[") {
+			delete(c.lines, k)
+		}
+	}
+}
+
+// setNode はドット区切りのキーの値だけを持つYAMLのマッピングを組み立てて設定に読み込む
+func (c *Config) setNode(key string, value *yaml.Node) error {
+	node := value
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		node = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: parts[i]}, node},
+		}
+	}
+	return node.Decode(c)
+}
+
+// EnvName は設定ファイルのキーに対応する環境変数名を返す
+func EnvName(key string) string {
+	return "RULEFORGE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// configKeys は Config の yaml タグから設定ファイルで指定できるキーを列挙する
+func configKeys() []configKey {
+	var keys []configKey
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				walk(field.Type, name)
+			case reflect.Slice:
+				// 構造体のリスト（projects）は環境変数では指定できない
+				if field.Type.Elem().Kind() != reflect.Struct {
+					keys = append(keys, configKey{path: name, list: true})
+				}
+			default:
+				keys = append(keys, configKey{path: name})
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// walkLeaves はYAMLのマッピングをたどり、値（スカラーまたはリスト）ごとにドット区切りのキーで fn を呼ぶ
+func walkLeaves(node *yaml.Node, prefix string, fn func(key string, node *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkLeaves(child, prefix, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walkLeaves(node.Content[i+1], key, fn)
+		}
+	default:
+		if prefix != "" {
+			fn(prefix, node)
+		}
+	}
+}
+
+// dropEmptyToken は値が空になった auth.github-token をマッピングから取り除く
+func dropEmptyToken(root *yaml.Node) {
+	m := documentMapping(root)
+	if m == nil {
+		return
+	}
+	i := mappingIndex(m, "auth")
+	if i < 0 || m.Content[i+1].Kind != yaml.MappingNode {
+		return
+	}
+	auth := m.Content[i+1]
+	if j := mappingIndex(auth, "github-token"); j >= 0 && auth.Content[j+1].Kind == yaml.ScalarNode && auth.Content[j+1].Value == "" {
+		auth.Content = append(auth.Content[:j], auth.Content[j+2:]...)
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// ベースリポジトリでのリポジトリ固有のルールの配置
+const (
+	// <repo-name>/ に配置する（所有者の異なる同名のリポジトリは同じディレクトリになる）
+	LayoutFlat = "flat"
+
+	// <repo-owner>/<repo-name>/ に配置する
+	LayoutOwner = "owner"
+)
+
+// RepoDirs はリポジトリ固有のルールを探すベースリポジトリのディレクトリを優先順に返す
+// owner 形式では、移行前の flat 形式のディレクトリも後ろに含める
+func RepoDirs(layout, owner, repo string) []string {
+	if repo == "" {
+		return nil
+	}
+	if layout == LayoutOwner && owner != "" {
+		return []string{path.Join(owner, repo), repo}
+	}
+	return []string{repo}
+}
+
+// RepoDirs はカレントリポジトリのルールを探すベースリポジトリのディレクトリを優先順に返す
+// namespace が指定されている場合はそのディレクトリだけを使い、
+// サブプロジェクトではリポジトリのディレクトリ配下のサブプロジェクトのパスを使う
+func (c *Config) RepoDirs() []string {
+	if c.Namespace != "" {
+		return []string{c.Namespace}
+	}
+	dirs := RepoDirs(c.Layout, c.RepoOwner, c.RepoName)
+	if c.ProjectPath != "" {
+		for i := range dirs {
+			dirs[i] = path.Join(dirs[i], c.ProjectPath)
+		}
+	}
+	return dirs
+}
+
+// RepoDir はカレントリポジトリのルールをアップロードするベースリポジトリのディレクトリを返す
+// リポジトリ名がわからない場合は空を返す
+func (c *Config) RepoDir() string {
+	if dirs := c.RepoDirs(); len(dirs) > 0 {
+		return dirs[0]
+	}
+	return ""
+}
+
+// BranchPrefix はアップロード用のブランチ名に付けるリポジトリごとの接頭辞を返す
+// owner 形式では所有者を含めるため、所有者の異なる同名のリポジトリでもブランチが重ならない
+func (c *Config) BranchPrefix() string {
+	return strings.ReplaceAll(c.RepoDir(), "/", "-")
+}
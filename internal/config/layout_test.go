@@ -0,0 +1,32 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepoDirs(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		dirs         []string
+		branchPrefix string
+	}{
+		{"flat 形式", Config{Layout: LayoutFlat, RepoOwner: "org-a", RepoName: "api"}, []string{"api"}, "api"},
+		{"未指定は flat 形式", Config{RepoOwner: "org-a", RepoName: "api"}, []string{"api"}, "api"},
+		{"owner 形式は flat 形式にフォールバック", Config{Layout: LayoutOwner, RepoOwner: "org-a", RepoName: "api"}, []string{"org-a/api", "api"}, "org-a-api"},
+		{"サブグループ", Config{Layout: LayoutOwner, RepoOwner: "group/sub", RepoName: "app"}, []string{"group/sub/app", "app"}, "group-sub-app"},
+		{"リポジトリ名が不明", Config{Layout: LayoutOwner, RepoOwner: "org-a"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.RepoDirs(); !reflect.DeepEqual(got, tt.dirs) {
+				t.Errorf("RepoDirs: 期待値 %v, 実際の値 %v", tt.dirs, got)
+			}
+			if got := tt.cfg.BranchPrefix(); got != tt.branchPrefix {
+				t.Errorf("BranchPrefix: 期待値 %q, 実際の値 %q", tt.branchPrefix, got)
+			}
+		})
+	}
+}
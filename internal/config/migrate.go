@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/hiroyannnn/ruleforge/internal/diff"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion は現在の設定ファイルの形式のバージョン
+// version のない設定ファイルはバージョン1として扱う
+const CurrentVersion = 2
+
+// migration は設定ファイルの形式を1つ前のバージョンから更新する
+type migration struct {
+	// 更新後のバージョン
+	to int
+
+	// 変更内容の説明（config migrate で表示）
+	description string
+
+	// ドキュメントのルートのマッピングを書き換える
+	apply func(m *yaml.Node)
+}
+
+// migrations はバージョンの古い順に並べる
+var migrations = []migration{
+	{to: 2, description: "github-token、app、api-url、oauth-client-id を auth にまとめる", apply: groupAuth},
+}
+
+// Migrate は設定ファイルの内容を現在の形式に更新し、適用した変更の説明を返す
+// 更新が不要な場合は元の内容をそのまま返す。コメントは移動したキーとともに保持される
+func Migrate(data []byte) ([]byte, []string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("設定ファイルの解析に失敗: %w", err)
+	}
+
+	applied, err := migrateNode(&root)
+	if err != nil || len(applied) == 0 {
+		return data, nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, nil, fmt.Errorf("設定ファイルの出力に失敗: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("設定ファイルの出力に失敗: %w", err)
+	}
+	return separateSections(buf.Bytes()), applied, nil
+}
+
+// MigrateFile は設定ファイルを現在の形式に更新する
+// showDiff が true の場合はファイルを書き換えずに差分を表示する
+// 結果には設定ファイルの状態（migrated、outdated、unchanged）と適用した変更の説明を記録する
+func MigrateFile(path string, out io.Writer, showDiff bool) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(migrateFile(path, out, showDiff, res))
+}
+
+func migrateFile(path string, out io.Writer, showDiff bool, res *output.Result) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+
+	migrated, applied, err := Migrate(data)
+	if err != nil {
+		return fmt.Errorf("設定ファイル %s: %w", path, err)
+	}
+	if len(applied) == 0 {
+		res.Files = append(res.Files, output.File{Path: path, Status: output.StatusUnchanged})
+		fmt.Fprintf(out, "%s は現在の形式（バージョン %d）です\n", path, CurrentVersion)
+		return nil
+	}
+	res.Migrations = applied
+
+	if showDiff {
+		res.Files = append(res.Files, output.File{Path: path, Status: output.StatusOutdated})
+		fmt.Fprint(out, diff.Unified(path, path, string(data), string(migrated)))
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+	if err := os.WriteFile(path, migrated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("設定ファイルの書き込みに失敗: %w", err)
+	}
+	res.Files = append(res.Files, output.File{Path: path, Status: output.StatusMigrated})
+
+	fmt.Fprintf(out, "%s をバージョン %d の形式に更新しました\n", path, CurrentVersion)
+	for _, description := range applied {
+		fmt.Fprintf(out, "  - %s\n", description)
+	}
+	return nil
+}
+
+// separateSections は yaml.v3 の出力で失われる空行を、ルートのキーのコメントの前に入れ直す
+func separateSections(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	out := make([][]byte, 0, len(lines))
+	for i, line := range lines {
+		if i > 0 && bytes.HasPrefix(line, []byte("#")) {
+			prev := lines[i-1]
+			if len(bytes.TrimSpace(prev)) > 0 && !bytes.HasPrefix(bytes.TrimSpace(prev), []byte("#")) {
+				out = append(out, nil)
+			}
+		}
+		out = append(out, line)
+	}
+	return bytes.Join(out, []byte("\n"))
+}
+
+// migrateNode は設定ファイルのノードを現在の形式に書き換え、適用した変更の説明を返す
+func migrateNode(root *yaml.Node) ([]string, error) {
+	m := documentMapping(root)
+	if m == nil {
+		return nil, nil
+	}
+
+	version := 1
+	if i := mappingIndex(m, "version"); i >= 0 {
+		v, err := strconv.Atoi(m.Content[i+1].Value)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("%d行目: version には1以上の整数を指定してください: %s", m.Content[i+1].Line, m.Content[i+1].Value)
+		}
+		version = v
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("設定ファイルの形式（バージョン %d）はこの ruleforge が対応するバージョン %d より新しいため、ruleforge を更新してください", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return nil, nil
+	}
+
+	var applied []string
+	for _, mig := range migrations {
+		if mig.to > version {
+			mig.apply(m)
+			applied = append(applied, fmt.Sprintf("バージョン %d: %s", mig.to, mig.description))
+		}
+	}
+	setVersion(m, CurrentVersion)
+	return applied, nil
+}
+
+// groupAuth はルートにある認証の設定を auth のマッピングに移動する（バージョン1 → 2）
+func groupAuth(m *yaml.Node) {
+	legacy := map[string]bool{"github-token": true, "app": true, "api-url": true, "oauth-client-id": true}
+
+	// ファイル内の順序を保って取り出す
+	var moved, rest []*yaml.Node
+	insertAt := -1
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if legacy[m.Content[i].Value] {
+			if insertAt < 0 {
+				insertAt = len(rest)
+			}
+			moved = append(moved, m.Content[i], m.Content[i+1])
+			continue
+		}
+		rest = append(rest, m.Content[i], m.Content[i+1])
+	}
+	m.Content = rest
+	if len(moved) == 0 {
+		return
+	}
+
+	auth := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if i := mappingIndex(m, "auth"); i >= 0 && m.Content[i+1].Kind == yaml.MappingNode {
+		auth = m.Content[i+1]
+	} else {
+		// 最初に移動したキーのコメントは auth の前に付け直す
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "auth", HeadComment: moved[0].HeadComment}
+		moved[0].HeadComment = ""
+		content := append([]*yaml.Node{key, auth}, m.Content[insertAt:]...)
+		m.Content = append(m.Content[:insertAt], content...)
+	}
+
+	for i := 0; i+1 < len(moved); i += 2 {
+		// auth に同じキーがある場合は auth の値を優先する
+		if mappingIndex(auth, moved[i].Value) < 0 {
+			auth.Content = append(auth.Content, moved[i], moved[i+1])
+		}
+	}
+}
+
+// setVersion は version の値を設定する。ない場合は先頭に追加する
+func setVersion(m *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if i := mappingIndex(m, "version"); i >= 0 {
+		m.Content[i+1].Value = value
+		m.Content[i+1].Tag = "!!int"
+		return
+	}
+	m.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version", HeadComment: "# 設定ファイルの形式のバージョン（ruleforge config migrate で更新）"},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, m.Content...)
+}
+
+// documentMapping はドキュメントのルートのマッピングを返す（空のドキュメントの場合は nil）
+func documentMapping(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode {
+		return root.Content[0]
+	}
+	return nil
+}
+
+// mappingIndex はマッピングのキーの位置を返す（ない場合は -1）
+func mappingIndex(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
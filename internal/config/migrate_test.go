@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyConfig = `# ベースリポジトリ
+base-repo: https://github.com/owner/rules
+
+# トークンは環境変数から読み込む
+github-token: ${RF_MIGRATE_TOKEN}
+
+# GitHub Enterprise Server
+api-url: https://github.example.com/api/v3/ # 社内のGHES
+app:
+  app-id: 7
+  installation-id: 42
+  private-key-file: app.pem
+
+message: "Update rules"
+`
+
+func TestMigrate(t *testing.T) {
+	migrated, applied, err := Migrate([]byte(legacyConfig))
+	if err != nil {
+		t.Fatalf("移行に失敗: %v", err)
+	}
+	if len(applied) != 1 || !strings.Contains(applied[0], "auth") {
+		t.Errorf("適用した変更: %v", applied)
+	}
+
+	expected := `# 設定ファイルの形式のバージョン（ruleforge config migrate で更新）
+version: 2
+
+# ベースリポジトリ
+base-repo: https://github.com/owner/rules
+
+# トークンは環境変数から読み込む
+auth:
+  github-token: ${RF_MIGRATE_TOKEN}
+  # GitHub Enterprise Server
+  api-url: https://github.example.com/api/v3/ # 社内のGHES
+  app:
+    app-id: 7
+    installation-id: 42
+    private-key-file: app.pem
+message: "Update rules"
+`
+	if string(migrated) != expected {
+		t.Errorf("移行後の内容が一致しません:\n期待値:\n%s\n実際の値:\n%s", expected, migrated)
+	}
+
+	// 現在の形式のファイルは変更しない
+	again, applied, err := Migrate(migrated)
+	if err != nil || len(applied) != 0 || !bytes.Equal(again, migrated) {
+		t.Errorf("現在の形式のファイルが変更されました: %v %v", applied, err)
+	}
+
+	// 新しいバージョンの形式はエラーにする
+	if _, _, err := Migrate([]byte("version: 99\n")); err == nil || !strings.Contains(err.Error(), "ruleforge を更新") {
+		t.Errorf("新しい形式でエラーが期待されました: %v", err)
+	}
+}
+
+func TestLoadLegacyConfig(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("RF_MIGRATE_TOKEN", "token-value")
+
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	// 古い形式のファイルも読み込み時に変換される
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("設定の読み込みに失敗: %v", err)
+	}
+	if cfg.Auth.GitHubToken != "token-value" || cfg.Auth.APIURL != "https://github.example.com/api/v3/" || cfg.Auth.App.InstallationID != 42 {
+		t.Errorf("認証の設定が読み込まれていません: %+v", cfg.Auth)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version: 期待値 %d, 実際の値 %d", CurrentVersion, cfg.Version)
+	}
+	if len(cfg.Outdated) != 1 || cfg.Outdated[0] != path {
+		t.Errorf("古い形式のファイルとして報告されていません: %v", cfg.Outdated)
+	}
+	// 移動したキーでも元の行番号が報告される
+	if got := cfg.Origins["auth.api-url"]; got != "設定ファイル ("+path+":8)" {
+		t.Errorf("auth.api-url の取得元: %s", got)
+	}
+
+	var out bytes.Buffer
+	res, err := MigrateFile(path, &out, true)
+	if err != nil {
+		t.Fatalf("差分の表示に失敗: %v", err)
+	}
+	if len(res.Files) != 1 || res.Files[0].Status != "outdated" || len(res.Migrations) != 1 {
+		t.Errorf("差分の表示の結果が一致しません: %+v", res)
+	}
+	if !strings.Contains(out.String(), "+auth:") || !strings.Contains(out.String(), "-github-token: ${RF_MIGRATE_TOKEN}") {
+		t.Errorf("差分が表示されていません:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyConfig {
+		t.Error("--diff でファイルが書き換えられました")
+	}
+
+	out.Reset()
+	res, err = MigrateFile(path, &out, false)
+	if err != nil {
+		t.Fatalf("移行に失敗: %v", err)
+	}
+	if len(res.Files) != 1 || res.Files[0].Status != "migrated" {
+		t.Errorf("移行の結果が一致しません: %+v", res.Files)
+	}
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("移行後の設定の読み込みに失敗: %v", err)
+	}
+	if len(cfg.Outdated) != 0 || cfg.Auth.GitHubToken != "token-value" {
+		t.Errorf("移行後の設定が正しくありません: %v %+v", cfg.Outdated, cfg.Auth)
+	}
+}
+
+func TestLoadIgnoresVersionEnv(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	// CI でツール自身のバージョンとして設定されることがある
+	t.Setenv("RULEFORGE_VERSION", "v1.2.3")
+
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	if err := os.WriteFile(path, []byte("version: 2\nbase-repo: owner/rules\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("RULEFORGE_VERSION があると読み込みに失敗しました: %v", err)
+	}
+	if cfg.Version != CurrentVersion || strings.Contains(cfg.Origins["version"], "環境変数") {
+		t.Errorf("version が環境変数で上書きされました: %d (%s)", cfg.Version, cfg.Origins["version"])
+	}
+}
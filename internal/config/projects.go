@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// originProjectFile はサブプロジェクトの設定ファイルの取得元の表示名
+const originProjectFile = "サブプロジェクトの設定ファイル"
+
+// サブプロジェクトの設定ファイルを探さないディレクトリ（依存パッケージやビルド成果物）
+var skipDirs = map[string]bool{
+	"__pycache__":  true,
+	"build":        true,
+	"dist":         true,
+	"node_modules": true,
+	"target":       true,
+	"third_party":  true,
+	"vendor":       true,
+}
+
+// maxProjectDepth はサブプロジェクトの設定ファイルを探すルートからのディレクトリの深さの上限
+// Git フックを含むすべてのコマンドで検索するため、大きな作業ツリーでも全体をたどらないようにする
+const maxProjectDepth = 4
+
+// Project はコマンドを実行するモノレポのプロジェクト（ルートまたはサブプロジェクト）
+type Project struct {
+	// リポジトリのルートからの / 区切りの相対パス（ルートの場合は "."）
+	Path string
+
+	// プロジェクトの設定
+	Config *Config
+}
+
+// LoadProjects は設定を読み込み、ルートとサブプロジェクトをそれぞれの設定とともに返す
+// サブプロジェクトは、ルートの設定ファイルの projects と、サブディレクトリにある同じ名前の設定ファイルで指定する
+// サブディレクトリの設定ファイルはルートの設定ファイルの上に重ねて読み込み、local-dir はそのディレクトリからの相対パスとする
+// サブディレクトリの設定ファイルで local-dir を指定しない場合は、そのディレクトリ自体を local-dir とする
+// ルートは、サブプロジェクトがない場合か、target-files を明示的に指定した場合に含める
+func LoadProjects(configFile string, overrides ...Override) ([]*Project, error) {
+	root, err := Load(configFile, overrides...)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []*Project
+	seen := map[string]string{}
+	for i, p := range root.Projects {
+		sub := *root
+		sub.Projects = nil
+		sub.ProjectPath = path.Clean(p.Path)
+		sub.LocalDir = filepath.Join(root.LocalDir, filepath.FromSlash(p.Path))
+		sub.Namespace = p.Namespace
+		if len(p.Files) > 0 {
+			sub.Files = p.Files
+		}
+		projects = append(projects, &Project{Path: sub.ProjectPath, Config: &sub})
+		seen[sub.ProjectPath] = root.location(fmt.Sprintf("projects[%d]", i))
+	}
+
+	var nested []string
+	if configFile != "" {
+		nested, err = findProjectFiles(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range nested {
+		dir := path.Dir(rel)
+		if loc, ok := seen[dir]; ok {
+			return nil, fmt.Errorf("サブプロジェクト %s は %s の projects と %s の両方で指定されています", dir, loc, rel)
+		}
+
+		file := filepath.Join(filepath.Dir(configFile), filepath.FromSlash(rel))
+		sub, err := load([]fileLayer{
+			{path: configFile, label: originRepoFile},
+			{path: file, label: originProjectFile},
+		}, overrides)
+		if err != nil {
+			return nil, err
+		}
+		if loc := sub.lines["projects"]; strings.HasPrefix(loc, file+":") {
+			return nil, fmt.Errorf("%s: サブプロジェクトの設定ファイルでは projects を指定できません", loc)
+		}
+		// ルートの設定ファイルの namespace は全サブプロジェクトで同じディレクトリになるため引き継がない
+		if strings.HasPrefix(sub.lines["namespace"], configFile+":") {
+			sub.Namespace = ""
+		}
+		sub.Projects = nil
+		sub.ProjectPath = dir
+		// ルートの設定ファイルやフラグの local-dir はルートのディレクトリを指すため引き継がない
+		if !strings.HasPrefix(sub.lines["local-dir"], file+":") {
+			sub.LocalDir = filepath.Dir(file)
+		} else if !filepath.IsAbs(sub.LocalDir) {
+			sub.LocalDir = filepath.Join(filepath.Dir(file), sub.LocalDir)
+		}
+		projects = append(projects, &Project{Path: dir, Config: sub})
+	}
+
+	sort.SliceStable(projects, func(i, j int) bool { return projects[i].Path < projects[j].Path })
+
+	// サブプロジェクトだけのモノレポでは、ルートのデフォルトの target-files を対象にしない
+	if _, explicit := root.Origins["target-files"]; len(projects) == 0 || explicit {
+		root.Projects = nil
+		projects = append([]*Project{{Path: ".", Config: root}}, projects...)
+	}
+
+	// サブプロジェクトのディレクトリはルートのディレクトリの中にあるため、
+	// sync-deletions でほかのプロジェクトのルールを削除しないよう各プロジェクトに渡す
+	for _, p := range projects {
+		for _, other := range projects {
+			if dir := other.Config.RepoDir(); other != p && dir != "" {
+				p.Config.OtherProjectDirs = append(p.Config.OtherProjectDirs, dir)
+			}
+		}
+	}
+	return projects, nil
+}
+
+// findProjectFiles はルートの設定ファイルのディレクトリ配下にある同じ名前の設定ファイルを探し、
+// ルートのディレクトリからの / 区切りの相対パスを返す
+// 隠しディレクトリ、依存パッケージやビルド成果物のディレクトリ、別のGitリポジトリ（サブモジュールなど）と、
+// maxProjectDepth より深いディレクトリは探さない
+func findProjectFiles(configFile string) ([]string, error) {
+	rootDir := filepath.Dir(configFile)
+	name := filepath.Base(configFile)
+
+	var found []string
+	err := filepath.WalkDir(rootDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if d.Name() == name && filepath.Dir(p) != rootDir {
+				rel, err := filepath.Rel(rootDir, p)
+				if err != nil {
+					return err
+				}
+				found = append(found, filepath.ToSlash(rel))
+			}
+			return nil
+		}
+		if p == rootDir {
+			return nil
+		}
+		if skipDirs[d.Name()] || d.Name()[0] == '.' {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(rootDir, p)
+		if err != nil {
+			return err
+		}
+		if strings.Count(filepath.ToSlash(rel), "/")+1 > maxProjectDepth {
+			return filepath.SkipDir
+		}
+		if _, err := os.Lstat(filepath.Join(p, ".git")); err == nil {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("サブプロジェクトの設定ファイルの検索に失敗: %w", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles はディレクトリに設定ファイルを作成する（キーは / 区切りの相対パス）
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("設定ファイルの作成に失敗: %v", err)
+		}
+	}
+}
+
+func TestLoadProjects(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		".ruleforge.yaml": `base-repo: org/rules
+repo-owner: org
+repo-name: mono
+namespace: shared
+projects:
+  - path: services/billing
+    target-files: [CLAUDE.md]
+  - path: services/search
+    namespace: search-rules
+`,
+		"apps/web/.ruleforge.yaml": `target-files:
+  - .cursor/rules.md
+  - AGENTS.md
+`,
+		// 隠しディレクトリ、依存パッケージやビルド成果物のディレクトリ、深すぎるディレクトリの設定ファイルは対象外
+		".cache/.ruleforge.yaml":           "target-files: [x.md]\n",
+		"node_modules/pkg/.ruleforge.yaml": "target-files: [x.md]\n",
+		"dist/web/.ruleforge.yaml":         "target-files: [x.md]\n",
+		"a/b/c/d/e/.ruleforge.yaml":        "target-files: [x.md]\n",
+	})
+
+	projects, err := LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+	if err != nil {
+		t.Fatalf("プロジェクトの読み込みに失敗: %v", err)
+	}
+
+	// ルートは target-files を指定していないため対象外
+	expected := []struct {
+		path     string
+		localDir string
+		files    []string
+		repoDir  string
+	}{
+		{"apps/web", filepath.Join(dir, "apps", "web"), []string{".cursor/rules.md", "AGENTS.md"}, "mono/apps/web"},
+		{"services/billing", filepath.Join("services", "billing"), []string{"CLAUDE.md"}, "mono/services/billing"},
+		{"services/search", filepath.Join("services", "search"), []string{".cursor/rules.md"}, "search-rules"},
+	}
+	if len(projects) != len(expected) {
+		t.Fatalf("プロジェクト数: 期待値 %d, 実際の値 %d", len(expected), len(projects))
+	}
+	for i, want := range expected {
+		got := projects[i]
+		if got.Path != want.path {
+			t.Errorf("%d: Path: 期待値 %s, 実際の値 %s", i, want.path, got.Path)
+			continue
+		}
+		if got.Config.LocalDir != want.localDir {
+			t.Errorf("%s: LocalDir: 期待値 %s, 実際の値 %s", want.path, want.localDir, got.Config.LocalDir)
+		}
+		if !reflect.DeepEqual(got.Config.Files, want.files) {
+			t.Errorf("%s: Files: 期待値 %v, 実際の値 %v", want.path, want.files, got.Config.Files)
+		}
+		if got.Config.RepoDir() != want.repoDir {
+			t.Errorf("%s: RepoDir: 期待値 %s, 実際の値 %s", want.path, want.repoDir, got.Config.RepoDir())
+		}
+		if got.Config.BaseRepo != "org/rules" {
+			t.Errorf("%s: ルートの設定を引き継いでいません: %s", want.path, got.Config.BaseRepo)
+		}
+	}
+}
+
+func TestLoadProjectsRoot(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	// サブプロジェクトがなければルートだけを返す
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{".ruleforge.yaml": "base-repo: org/rules\nrepo-name: app\n"})
+	projects, err := LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+	if err != nil {
+		t.Fatalf("プロジェクトの読み込みに失敗: %v", err)
+	}
+	if len(projects) != 1 || projects[0].Path != "." || projects[0].Config.RepoDir() != "app" {
+		t.Fatalf("ルートのプロジェクトが返されていません: %+v", projects)
+	}
+
+	// target-files を明示したルートはサブプロジェクトと一緒に実行する
+	writeConfigFiles(t, dir, map[string]string{
+		".ruleforge.yaml":     "base-repo: org/rules\nrepo-name: app\ntarget-files: [AGENTS.md]\n",
+		"lib/.ruleforge.yaml": "target-files: [CLAUDE.md]\n",
+	})
+	projects, err = LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+	if err != nil {
+		t.Fatalf("プロジェクトの読み込みに失敗: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Path != "." || projects[1].Path != "lib" {
+		t.Fatalf("ルートとサブプロジェクトが返されていません: %+v", projects)
+	}
+}
+
+func TestLoadProjectsErrors(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			"projects と設定ファイルの重複",
+			map[string]string{
+				".ruleforge.yaml":     "base-repo: org/rules\nprojects:\n  - path: lib\n",
+				"lib/.ruleforge.yaml": "target-files: [CLAUDE.md]\n",
+			},
+			"両方で指定されています",
+		},
+		{
+			"サブプロジェクトの projects",
+			map[string]string{
+				".ruleforge.yaml":     "base-repo: org/rules\n",
+				"lib/.ruleforge.yaml": "projects:\n  - path: nested\n",
+			},
+			"projects を指定できません",
+		},
+		{
+			"リポジトリ外のパス",
+			map[string]string{".ruleforge.yaml": "base-repo: org/rules\nprojects:\n  - path: ../other\n"},
+			"projects[0].path",
+		},
+		{
+			"不明なキー",
+			map[string]string{".ruleforge.yaml": "base-repo: org/rules\nprojects:\n  - path: lib\n    target_files: [a.md]\n"},
+			"projects[0].target_files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFiles(t, dir, tt.files)
+			_, err := LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%q を含むエラーが期待されました: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadProjectsOtherProjectDirs(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	// サブプロジェクトのディレクトリはルートのディレクトリの中にある
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		".ruleforge.yaml":                  "base-repo: org/rules\nrepo-name: mono\ntarget-files: [AGENTS.md]\n",
+		"services/billing/.ruleforge.yaml": "target-files: [CLAUDE.md]\n",
+	})
+	projects, err := LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+	if err != nil {
+		t.Fatalf("プロジェクトの読み込みに失敗: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("ルートとサブプロジェクトが返されていません: %+v", projects)
+	}
+
+	if got := projects[0].Config.OtherProjectDirs; !reflect.DeepEqual(got, []string{"mono/services/billing"}) {
+		t.Errorf("ルートのほかのプロジェクトのディレクトリ: %v", got)
+	}
+	if got := projects[1].Config.OtherProjectDirs; !reflect.DeepEqual(got, []string{"mono"}) {
+		t.Errorf("サブプロジェクトのほかのプロジェクトのディレクトリ: %v", got)
+	}
+}
+
+func TestLoadProjectsLocalDir(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	// ルートの local-dir は引き継がず、サブプロジェクトの設定ファイルの local-dir はそのディレクトリからの相対パスとする
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		".ruleforge.yaml":          "base-repo: org/rules\nrepo-name: mono\nlocal-dir: docs\ntarget-files: [AGENTS.md]\n",
+		"lib/.ruleforge.yaml":      "target-files: [CLAUDE.md]\n",
+		"apps/web/.ruleforge.yaml": "local-dir: rules\ntarget-files: [CLAUDE.md]\n",
+	})
+	projects, err := LoadProjects(filepath.Join(dir, ".ruleforge.yaml"))
+	if err != nil {
+		t.Fatalf("プロジェクトの読み込みに失敗: %v", err)
+	}
+
+	expected := map[string]string{
+		".":        "docs",
+		"apps/web": filepath.Join(dir, "apps", "web", "rules"),
+		"lib":      filepath.Join(dir, "lib"),
+	}
+	if len(projects) != len(expected) {
+		t.Fatalf("プロジェクト数: 期待値 %d, 実際の値 %d", len(expected), len(projects))
+	}
+	for _, p := range projects {
+		if want := expected[p.Path]; p.Config.LocalDir != want {
+			t.Errorf("%s: LocalDir: 期待値 %s, 実際の値 %s", p.Path, want, p.Config.LocalDir)
+		}
+	}
+}
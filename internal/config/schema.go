@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// descriptions はJSON Schemaに含める各キーの説明（エディタの補完で表示される）
+var descriptions = map[string]string{
+	"version":                     "設定ファイルの形式のバージョン（古い形式は ruleforge config migrate で更新できる）",
+	"base-repo":                   "ベースリポジトリ（owner/repo 形式またはURL）",
+	"target-files":                "ダウンロード・アップロードの対象ファイル（リポジトリのルートからの相対パス）",
+	"auth":                        "GitHub API の認証設定",
+	"auth.github-token":           "GitHubトークン（${GITHUB_TOKEN} のように環境変数を参照できる）",
+	"auth.app":                    "GitHub App 認証の設定（設定した場合は github-token より優先）",
+	"auth.app.app-id":             "GitHub App の App ID",
+	"auth.app.installation-id":    "対象の組織またはユーザーへのインストールID",
+	"auth.app.private-key-file":   "GitHub App の秘密鍵（PEM形式）のファイルパス",
+	"auth.api-url":                "GitHub APIのURL（GitHub Enterprise Server 用）",
+	"auth.oauth-client-id":        "ruleforge login で使う OAuth App のクライアントID",
+	"message":                     "コミットメッセージとPRのタイトル",
+	"verbose":                     "詳細なログ出力",
+	"local-dir":                   "ルールを読み書きするローカルディレクトリ",
+	"branch-name":                 "アップロード用のブランチ名（リポジトリごとに固定して既存のPRを再利用する）",
+	"repo-name":                   "カレントリポジトリ名（未指定の場合は git remote から検出）",
+	"repo-owner":                  "カレントリポジトリの所有者（未指定の場合は git remote から検出）",
+	"layout":                      "ベースリポジトリでのリポジトリ固有のルールの配置（flat: <repo-name>/、owner: <repo-owner>/<repo-name>/）",
+	"namespace":                   "ベースリポジトリでのリポジトリ固有のルールのディレクトリ（指定した場合は repo-name と layout より優先）",
+	"projects":                    "モノレポのサブプロジェクト（サブディレクトリの .ruleforge.yaml でも指定できる）",
+	"projects.path":               "サブプロジェクトのディレクトリ（リポジトリのルートからの相対パス）",
+	"projects.target-files":       "サブプロジェクトの対象ファイル（省略時はルートの target-files）",
+	"projects.namespace":          "サブプロジェクトのベースリポジトリのディレクトリ（省略時はリポジトリのディレクトリ配下の path）",
+	"sync-deletions":              "ローカルにないファイルをベースリポジトリから削除する",
+	"concurrency":                 "ダウンロード時の並列数",
+	"retry":                       "GitHub API呼び出しのリトライ設定",
+	"retry.max-retries":           "最大リトライ回数（0でリトライしない）",
+	"retry.base-delay":            "指数バックオフの初期待機時間（例: 1s）",
+	"retry.max-wait":              "1回あたりの最大待機時間（例: 2m）",
+	"pull-request":                "作成するPRに設定するラベルやレビュアー",
+	"pull-request.labels":         "PRに付与するラベル",
+	"pull-request.reviewers":      "レビューを依頼するユーザー",
+	"pull-request.team-reviewers": "レビューを依頼するチーム（slug）",
+	"pull-request.assignees":      "PRにアサインするユーザー",
+	"pull-request.draft":          "PRをドラフトとして作成する",
+	"pull-request.milestone":      "PRに設定するマイルストーン（タイトルまたは番号）",
+	"fleet":                       "ルールを配布する利用側リポジトリの設定（fleet sync 用）",
+	"fleet.repos":                 "配布先リポジトリ（owner/repo 形式またはURL）",
+	"fleet.branch-name":           "配布用のブランチ名",
+	"fleet.state-file":            "中断した同期を再開するための状態ファイル",
+}
+
+// 整数のキーの最小値
+var minimums = map[string]int{
+	"version":           1,
+	"concurrency":       1,
+	"retry.max-retries": 0,
+}
+
+// 文字列のキーで指定できる値
+var enums = map[string][]string{
+	"layout": {LayoutFlat, LayoutOwner},
+}
+
+// Go の time.ParseDuration が受け付ける形式（例: 1m30s）
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// JSONSchema は設定ファイルのJSON Schema（エディタの補完と検証用）を生成する
+func JSONSchema() ([]byte, error) {
+	schema := objectSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "RuleForge 設定ファイル"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("JSON Schema の生成に失敗: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// objectSchema は構造体の yaml タグから object 型のスキーマを組み立てる
+func objectSchema(t reflect.Type, prefix string) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		property := typeSchema(field.Type, key)
+		if desc := descriptions[key]; desc != "" {
+			property["description"] = desc
+		}
+		properties[name] = property
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema はGoの型に対応するスキーマを返す
+func typeSchema(t reflect.Type, key string) map[string]any {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return objectSchema(t, key)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), key)}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		schema := map[string]any{"type": "integer"}
+		if min, ok := minimums[key]; ok {
+			schema["minimum"] = min
+		}
+		return schema
+	default:
+		schema := map[string]any{"type": "string"}
+		if values, ok := enums[key]; ok {
+			schema["enum"] = values
+		}
+		return schema
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestJSONSchemaUpToDate(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSON Schema の生成に失敗: %v", err)
+	}
+	if !json.Valid(schema) {
+		t.Fatal("JSON Schema が正しいJSONではありません")
+	}
+
+	// リポジトリに含めているスキーマファイルが Config の定義と一致していること
+	committed, err := os.ReadFile("../../schema/ruleforge.schema.json")
+	if err != nil {
+		t.Fatalf("スキーマファイルの読み込みに失敗: %v", err)
+	}
+	if !bytes.Equal(committed, schema) {
+		t.Error("schema/ruleforge.schema.json が古くなっています。make schema で再生成してください")
+	}
+}
+
+func TestJSONSchemaDescriptions(t *testing.T) {
+	// 設定ファイルのキーにはすべて説明がある
+	for _, key := range configKeys() {
+		if descriptions[key.path] == "" {
+			t.Errorf("%s の説明がありません", key.path)
+		}
+	}
+}
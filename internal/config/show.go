@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// トークンの代わりに表示する文字列
+const maskedToken = "********"
+
+// Show は有効な設定をYAML形式で表示する（auth.github-token は表示しない）
+// withOrigin が true の場合は、値ごとに取得元（デフォルト値、設定ファイルの行、環境変数、フラグなど）を表示する
+// 結果には設定ファイルのキーで表した設定と、withOrigin が true の場合は取得元を記録する
+func Show(w io.Writer, cfg *Config, withOrigin bool) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(show(w, cfg, withOrigin, res))
+}
+
+func show(w io.Writer, cfg *Config, withOrigin bool, res *output.Result) error {
+	masked := *cfg
+	if masked.Auth.GitHubToken != "" {
+		masked.Auth.GitHubToken = maskedToken
+	}
+
+	var root yaml.Node
+	if err := root.Encode(&masked); err != nil {
+		return fmt.Errorf("設定の出力に失敗: %w", err)
+	}
+	settings := map[string]any{}
+	if err := root.Decode(&settings); err != nil {
+		return fmt.Errorf("設定の出力に失敗: %w", err)
+	}
+	res.Config = settings
+
+	if !withOrigin {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&masked); err != nil {
+			return fmt.Errorf("設定の出力に失敗: %w", err)
+		}
+		return encoder.Close()
+	}
+
+	res.Origins = map[string]string{}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	walkLeaves(&root, "", func(key string, node *yaml.Node) {
+		origin, ok := cfg.Origins[key]
+		if !ok {
+			origin = originDefault
+		}
+		res.Origins[key] = origin
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, formatValue(node), origin)
+	})
+	return tw.Flush()
+}
+
+// formatValue は表示用に値を1行の文字列にする
+func formatValue(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		pairs := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, node.Content[i].Value+": "+formatValue(node.Content[i+1]))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	if node.Kind == yaml.SequenceNode {
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			items = append(items, formatValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	if node.Value == "" {
+		return `""`
+	}
+	return node.Value
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem は設定の1件の問題
+type Problem struct {
+	// 問題のある設定のキー（リストの要素は target-files[1] のように添字を付ける）
+	Key string
+
+	// 値の指定箇所（設定ファイルのパスと行番号、環境変数名、フラグ名など）
+	Location string
+
+	// 問題の内容
+	Message string
+}
+
+func (p Problem) String() string {
+	var parts []string
+	if p.Location != "" {
+		parts = append(parts, p.Location)
+	}
+	if p.Key != "" {
+		parts = append(parts, p.Key)
+	}
+	return strings.Join(append(parts, p.Message), ": ")
+}
+
+// ValidationError は設定の検証で見つかったすべての問題
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("設定に %d 件の問題があります:", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate は読み込んだ設定の値（URL、パス、ブランチ名など）を検証し、見つかった問題をすべて返す
+// 必須項目の有無はコマンドごとに異なるため、空の値は検証しない
+func (c *Config) Validate() []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Location: c.location(key), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.BaseRepo != "" {
+		if err := validateRepo(c.BaseRepo); err != nil {
+			add("base-repo", "%v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, file := range c.Files {
+		key := fmt.Sprintf("target-files[%d]", i)
+		if err := validateRelativePath(file); err != nil {
+			add(key, "%v", err)
+			continue
+		}
+		if seen[path.Clean(file)] {
+			add(key, "%s が重複しています", file)
+		}
+		seen[path.Clean(file)] = true
+	}
+
+	if c.LocalDir == "" {
+		add("local-dir", "空にはできません")
+	}
+	if c.BranchName != "" {
+		if err := validateBranchName(c.BranchName); err != nil {
+			add("branch-name", "%v", err)
+		}
+	}
+	switch c.Layout {
+	case "", LayoutFlat:
+	case LayoutOwner:
+		if c.RepoName != "" && c.RepoOwner == "" {
+			add("repo-owner", "layout: owner ではリポジトリの所有者が必要です（git remote から検出できませんでした）")
+		}
+	default:
+		add("layout", "%s または %s を指定してください（%q が指定されています）", LayoutFlat, LayoutOwner, c.Layout)
+	}
+	if c.Concurrency < 1 {
+		add("concurrency", "1以上を指定してください（%d が指定されています）", c.Concurrency)
+	}
+
+	if c.Retry.MaxRetries < 0 {
+		add("retry.max-retries", "0以上を指定してください")
+	}
+	if c.Retry.BaseDelay < 0 {
+		add("retry.base-delay", "0以上を指定してください")
+	}
+	if c.Retry.MaxWait < 0 {
+		add("retry.max-wait", "0以上を指定してください")
+	}
+
+	if c.Auth.APIURL != "" {
+		if u, err := url.Parse(c.Auth.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("auth.api-url", "http:// または https:// で始まるURLを指定してください: %s", c.Auth.APIURL)
+		}
+	}
+
+	app := c.Auth.App
+	if app.AppID == 0 && (app.InstallationID != 0 || app.PrivateKeyFile != "") {
+		add("auth.app.app-id", "GitHub App の App ID が設定されていません")
+	}
+	if app.AppID != 0 {
+		if app.InstallationID == 0 {
+			add("auth.app.installation-id", "GitHub App のインストールIDが設定されていません")
+		}
+		if app.PrivateKeyFile == "" {
+			add("auth.app.private-key-file", "GitHub App の秘密鍵ファイルが設定されていません")
+		}
+	}
+
+	projectPaths := map[string]bool{}
+	for i, p := range c.Projects {
+		key := fmt.Sprintf("projects[%d]", i)
+		if err := validateRelativePath(p.Path); err != nil {
+			add(key+".path", "%v", err)
+		} else if projectPaths[path.Clean(p.Path)] {
+			add(key+".path", "%s が重複しています", p.Path)
+		}
+		projectPaths[path.Clean(p.Path)] = true
+		for j, file := range p.Files {
+			if err := validateRelativePath(file); err != nil {
+				add(fmt.Sprintf("%s.target-files[%d]", key, j), "%v", err)
+			}
+		}
+		if p.Namespace != "" {
+			if err := validateRelativePath(p.Namespace); err != nil {
+				add(key+".namespace", "%v", err)
+			}
+		}
+	}
+	if c.Namespace != "" {
+		if err := validateRelativePath(c.Namespace); err != nil {
+			add("namespace", "%v", err)
+		}
+	}
+
+	for i, repo := range c.Fleet.Repos {
+		if err := validateRepo(repo); err != nil {
+			add(fmt.Sprintf("fleet.repos[%d]", i), "%v", err)
+		}
+	}
+	if c.Fleet.BranchName != "" {
+		if err := validateBranchName(c.Fleet.BranchName); err != nil {
+			add("fleet.branch-name", "%v", err)
+		}
+	}
+
+	return problems
+}
+
+// location は設定のキーが指定された箇所を返す
+func (c *Config) location(key string) string {
+	if loc, ok := c.lines[key]; ok {
+		return loc
+	}
+	// リストの要素は、リスト全体の指定箇所を使う（環境変数やフラグで指定された場合）
+	if i := strings.Index(key, "["); i >= 0 {
+		key = key[:i]
+		if loc, ok := c.lines[key]; ok {
+			return loc
+		}
+	}
+	return c.Origins[key]
+}
+
+// checkKeys はYAMLのマッピングに設定にないキーが含まれていないかを調べる
+func checkKeys(node *yaml.Node, t reflect.Type, prefix, file string) []Problem {
+	if node.Kind == yaml.DocumentNode {
+		var problems []Problem
+		for _, child := range node.Content {
+			problems = append(problems, checkKeys(child, t, prefix, file)...)
+		}
+		return problems
+	}
+	// 構造体のリストは要素ごとに調べる
+	if node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice {
+		var problems []Problem
+		for i, item := range node.Content {
+			problems = append(problems, checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i), file)...)
+		}
+		return problems
+	}
+	if node.Kind != yaml.MappingNode || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		fieldType, ok := fields[keyNode.Value]
+		if !ok {
+			message := "不明なキーです"
+			if suggestion := suggestKey(keyNode.Value, fields); suggestion != "" {
+				message += fmt.Sprintf("（%s の誤りではありませんか）", suggestion)
+			}
+			problems = append(problems, Problem{Key: key, Location: fmt.Sprintf("%s:%d", file, keyNode.Line), Message: message})
+			continue
+		}
+		problems = append(problems, checkKeys(node.Content[i+1], fieldType, key, file)...)
+	}
+	return problems
+}
+
+// suggestKey は区切り文字や大文字小文字の違いだけのキーを探す（target_files → target-files など）
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "", ".", "").Replace(s))
+	}
+	for name := range fields {
+		if normalize(name) == normalize(key) {
+			return name
+		}
+	}
+	return ""
+}
+
+// typeErrorProblems は yaml.TypeError の各エラー（"line 3: cannot unmarshal ..."）を問題に変換する
+func typeErrorProblems(err *yaml.TypeError, file string) []Problem {
+	problems := make([]Problem, 0, len(err.Errors))
+	for _, msg := range err.Errors {
+		location := file
+		if rest, ok := strings.CutPrefix(msg, "line "); ok {
+			if n, after, ok := strings.Cut(rest, ": "); ok {
+				if _, convErr := strconv.Atoi(n); convErr == nil {
+					location = fmt.Sprintf("%s:%s", file, n)
+					msg = after
+				}
+			}
+		}
+		problems = append(problems, Problem{Location: location, Message: "値の型が正しくありません: " + msg})
+	}
+	return problems
+}
+
+// repoURLSchemes はリポジトリのURLとして受け付けるスキーム
+var repoURLSchemes = map[string]bool{"https": true, "http": true, "ssh": true, "git+ssh": true}
+
+// validateRepo はリポジトリの指定が owner/repo、https://<host>/owner/repo、ssh://git@<host>/owner/repo、
+// git@<host>:owner/repo のいずれかの形式かを検証する（ghclient.ParseRepoURL と git remote の形式に合わせる）
+func validateRepo(repo string) error {
+	rest := strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	switch {
+	case strings.Contains(rest, "://"):
+		u, err := url.Parse(rest)
+		if err != nil || !repoURLSchemes[u.Scheme] || u.Host == "" {
+			return fmt.Errorf("リポジトリのURLが不正です: %s", repo)
+		}
+		rest = strings.TrimPrefix(u.Path, "/")
+	case strings.HasPrefix(rest, "git@"):
+		_, after, ok := strings.Cut(rest, ":")
+		if !ok {
+			return fmt.Errorf("リポジトリのURLが不正です: %s", repo)
+		}
+		rest = after
+	}
+
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("リポジトリは owner/repo 形式またはURLで指定してください: %s", repo)
+	}
+	for _, part := range parts {
+		for _, r := range part {
+			if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.') {
+				return fmt.Errorf("リポジトリ名に使えない文字 %q が含まれています: %s", r, repo)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRelativePath はベースリポジトリ内の相対パスとして使えるかを検証する
+func validateRelativePath(p string) error {
+	switch {
+	case p == "":
+		return fmt.Errorf("空のパスは指定できません")
+	case strings.HasPrefix(p, "/") || strings.Contains(p, "\\") || (len(p) > 1 && p[1] == ':'):
+		return fmt.Errorf("相対パスを指定してください（/ 区切り）: %s", p)
+	}
+	cleaned := path.Clean(p)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return fmt.Errorf("リポジトリの外を指すパスは指定できません: %s", p)
+	}
+	return nil
+}
+
+// validateBranchName はブランチ名が git check-ref-format の規則を満たすかを検証する
+func validateBranchName(name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("ブランチ名 %q は使用できません（%s）", name, reason)
+	}
+
+	switch {
+	case name == "@":
+		return invalid("@ のみの名前")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/"):
+		return invalid("/ で始まるまたは終わる名前")
+	case strings.HasPrefix(name, "-"):
+		return invalid("- で始まる名前")
+	case strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock"):
+		return invalid(". または .lock で終わる名前")
+	case strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{"):
+		return invalid(".. や // や @{ を含む名前")
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return invalid(fmt.Sprintf("使えない文字 %q を含む名前", r))
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return invalid(". で始まる階層を含む名前")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		return &Config{
+			BaseRepo:    "https://github.com/owner/rules",
+			Files:       []string{".cursor/rules.md", "CLAUDE.md"},
+			LocalDir:    ".",
+			BranchName:  "update-agent-rules",
+			Concurrency: 4,
+			Fleet: FleetConfig{Repos: []string{
+				"owner/service-a",
+				"git@github.example.com:owner/service-b.git",
+				"ssh://git@github.com/owner/service-c.git",
+				"git+ssh://git@github.com/owner/service-d",
+			}},
+		}
+	}
+
+	if problems := valid().Validate(); len(problems) != 0 {
+		t.Fatalf("正しい設定で問題が報告されました: %v", problems)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		key    string
+	}{
+		{"ベースリポジトリの形式", func(c *Config) { c.BaseRepo = "not a repo" }, "base-repo"},
+		{"ベースリポジトリのパスが深すぎる", func(c *Config) { c.BaseRepo = "https://github.com/owner/repo/tree/main" }, "base-repo"},
+		{"絶対パス", func(c *Config) { c.Files[1] = "/etc/passwd" }, "target-files[1]"},
+		{"リポジトリ外のパス", func(c *Config) { c.Files[0] = "../secrets.md" }, "target-files[0]"},
+		{"重複したパス", func(c *Config) { c.Files[1] = "./.cursor/rules.md" }, "target-files[1]"},
+		{"ブランチ名の空白", func(c *Config) { c.BranchName = "update rules" }, "branch-name"},
+		{"ブランチ名の ..", func(c *Config) { c.BranchName = "rules..main" }, "branch-name"},
+		{"ブランチ名の .lock", func(c *Config) { c.BranchName = "rules.lock" }, "branch-name"},
+		{"並列数", func(c *Config) { c.Concurrency = 0 }, "concurrency"},
+		{"不明な配置", func(c *Config) { c.Layout = "nested" }, "layout"},
+		{"owner 形式で所有者が不明", func(c *Config) { c.Layout, c.RepoName = LayoutOwner, "api" }, "repo-owner"},
+		{"APIのURL", func(c *Config) { c.Auth.APIURL = "github.example.com/api/v3" }, "auth.api-url"},
+		{"App の設定不足", func(c *Config) { c.Auth.App.AppID = 1 }, "auth.app.installation-id"},
+		{"配布先リポジトリ", func(c *Config) { c.Fleet.Repos = append(c.Fleet.Repos, "service-e") }, "fleet.repos[4]"},
+		{"対応していないスキーム", func(c *Config) { c.BaseRepo = "ftp://github.com/owner/rules" }, "base-repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			problems := cfg.Validate()
+			if len(problems) == 0 {
+				t.Fatal("問題が報告されませんでした")
+			}
+			if problems[0].Key != tt.key {
+				t.Errorf("キー: 期待値 %s, 実際の値 %s (%v)", tt.key, problems[0].Key, problems)
+			}
+		})
+	}
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), ".ruleforge.yaml")
+	content := `base-repo: https://github.com/owner/rules
+target_files:
+  - .cursor/rules.md
+branch-name: "my branch"
+concurrency: many
+pull-request:
+  label: [rules]
+fleet:
+  repos:
+    - owner/service-a
+    - service-b
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("テスト設定ファイルの作成に失敗: %v", err)
+	}
+
+	_, err := Load(path)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidationError が期待されました: %v", err)
+	}
+
+	// すべての問題が設定ファイルの行番号付きで報告される
+	expected := []string{
+		path + ":2: target_files: 不明なキーです（target-files の誤りではありませんか）",
+		path + ":7: pull-request.label: 不明なキーです",
+		path + ":5: 値の型が正しくありません",
+		path + ":4: branch-name: ブランチ名",
+		path + ":11: fleet.repos[1]: リポジトリは owner/repo 形式",
+	}
+	if len(validationErr.Problems) != len(expected) {
+		t.Errorf("問題の件数: 期待値 %d, 実際の値 %d\n%v", len(expected), len(validationErr.Problems), err)
+	}
+	for _, want := range expected {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q が報告されていません:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHost はベースリポジトリのホストが特定できない場合に使うホスト
+const DefaultHost = "github.com"
+
+// git credential fill の最大待ち時間
+var gitCredentialTimeout = 5 * time.Second
+
+// source はトークンの取得元
+type source struct {
+	name   string
+	lookup func(host string) (string, error)
+}
+
+// sources はトークンを探す順序（設定ファイルの auth.github-token は呼び出し側で優先する）
+var sources = []source{
+	{"環境変数 GITHUB_TOKEN", envLookup("GITHUB_TOKEN")},
+	{"環境変数 GH_TOKEN", envLookup("GH_TOKEN")},
+	{"gh CLI の hosts.yml", fromGHHosts},
+	{"git credential fill", fromGitCredential},
+	{"ruleforge の認証情報ファイル", fromStore},
+}
+
+// Lookup は環境変数、gh CLI、git の認証ヘルパー、ruleforge の認証情報ファイルの順に
+// ホストのトークンを探し、見つかったトークンと取得元の名前を返す
+// 取得元の読み込みに失敗した場合は次の取得元を試す
+func Lookup(host string) (token, from string) {
+	if host == "" {
+		host = DefaultHost
+	}
+	for _, s := range sources {
+		token, err := s.lookup(host)
+		if err == nil && token != "" {
+			return token, s.name
+		}
+	}
+	return "", ""
+}
+
+// HostFromRepoURL はベースリポジトリのURLからホスト名を取り出す
+// owner/repo 形式の場合は github.com とみなす
+func HostFromRepoURL(repoURL string) string {
+	switch {
+	case strings.Contains(repoURL, "://"):
+		u, err := url.Parse(repoURL)
+		if err != nil || u.Hostname() == "" {
+			return DefaultHost
+		}
+		return u.Hostname()
+	case strings.Contains(repoURL, "@") && strings.Contains(repoURL, ":"):
+		// git@host:owner/repo.git 形式
+		rest := repoURL[strings.Index(repoURL, "@")+1:]
+		return strings.SplitN(rest, ":", 2)[0]
+	default:
+		return DefaultHost
+	}
+}
+
+func envLookup(name string) func(string) (string, error) {
+	return func(string) (string, error) {
+		return os.Getenv(name), nil
+	}
+}
+
+// fromGHHosts は gh CLI の hosts.yml からトークンを読み込む
+// 新しい gh CLI はトークンをOSのキーリングに保存するため、ファイルにない場合は見つからない扱いになる
+func fromGHHosts(host string) (string, error) {
+	path, err := ghHostsPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", fmt.Errorf("%s の解析に失敗: %w", path, err)
+	}
+	return hosts[host].OAuthToken, nil
+}
+
+// ghHostsPath は gh CLI の設定ディレクトリにある hosts.yml のパスを返す
+func ghHostsPath() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml"), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI", "hosts.yml"), nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml"), nil
+}
+
+// fromGitCredential は git の認証ヘルパーからトークン（パスワード）を取得する
+// 対話的な入力は求めない
+func fromGitCredential(host string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCredentialTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "password="); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,113 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// isolate は利用者の環境の認証情報を参照しないように環境変数を差し替える
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GH_CONFIG_DIR", filepath.Join(dir, "gh"))
+	t.Setenv("RULEFORGE_CONFIG_DIR", filepath.Join(dir, "ruleforge"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("ファイルの書き込みに失敗: %v", err)
+	}
+}
+
+func TestLookupOrder(t *testing.T) {
+	dir := isolate(t)
+
+	if token, from := Lookup("github.com"); token != "" {
+		t.Fatalf("認証情報がないのにトークンが見つかりました: %s", from)
+	}
+
+	// 優先順位の低いものから順に追加し、常に最後に追加したものが選ばれることを確認する
+	steps := []struct {
+		setup func()
+		token string
+		from  string
+	}{
+		{
+			setup: func() {
+				writeFile(t, filepath.Join(dir, "ruleforge", "credentials.yml"), "hosts:\n  github.com:\n    token: from-store\n")
+			},
+			token: "from-store",
+			from:  "ruleforge の認証情報ファイル",
+		},
+		{
+			setup: func() {
+				writeFile(t, filepath.Join(dir, "gitconfig"), "[credential]\n\thelper = \"!f() { echo username=x-access-token; echo password=from-git; }; f\"\n")
+			},
+			token: "from-git",
+			from:  "git credential fill",
+		},
+		{
+			setup: func() {
+				writeFile(t, filepath.Join(dir, "gh", "hosts.yml"), "github.com:\n  user: octocat\n  oauth_token: from-gh\n")
+			},
+			token: "from-gh",
+			from:  "gh CLI の hosts.yml",
+		},
+		{
+			setup: func() { t.Setenv("GH_TOKEN", "from-gh-token") },
+			token: "from-gh-token",
+			from:  "環境変数 GH_TOKEN",
+		},
+		{
+			setup: func() { t.Setenv("GITHUB_TOKEN", "from-github-token") },
+			token: "from-github-token",
+			from:  "環境変数 GITHUB_TOKEN",
+		},
+	}
+
+	for _, step := range steps {
+		step.setup()
+		token, from := Lookup("github.com")
+		if token != step.token || from != step.from {
+			t.Errorf("期待値 %s (%s), 実際の値 %s (%s)", step.token, step.from, token, from)
+		}
+	}
+
+	// 別のホストのトークンは使わない
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	writeFile(t, filepath.Join(dir, "gitconfig"), "")
+	if token, from := Lookup("github.example.com"); token != "" {
+		t.Errorf("別のホストのトークンが使われました: %s", from)
+	}
+}
+
+func TestHostFromRepoURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://github.com/owner/repo", "github.com"},
+		{"https://user@github.example.com:8443/owner/repo.git", "github.example.com"},
+		{"git@github.example.com:owner/repo.git", "github.example.com"},
+		{"owner/repo", "github.com"},
+		{"", "github.com"},
+	}
+
+	for _, tt := range tests {
+		if got := HostFromRepoURL(tt.url); got != tt.expected {
+			t.Errorf("HostFromRepoURL(%q) = %s, 期待値 %s", tt.url, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Store は ruleforge の認証情報ファイルに保存されたホストごとのトークン
+type Store struct {
+	Hosts map[string]HostCredential `yaml:"hosts"`
+}
+
+// HostCredential は1ホスト分の認証情報
+type HostCredential struct {
+	// アクセストークン
+	Token string `yaml:"token"`
+
+	// トークンのユーザー名（表示用）
+	User string `yaml:"user,omitempty"`
+}
+
+// ConfigDir は ruleforge のユーザー設定ディレクトリ（ユーザー設定ファイルと認証情報ファイルの置き場所）を返す
+// 環境変数 RULEFORGE_CONFIG_DIR でディレクトリを変更できる
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("RULEFORGE_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("設定ディレクトリを特定できません: %w", err)
+	}
+	return filepath.Join(dir, "ruleforge"), nil
+}
+
+// StorePath は認証情報ファイルのパスを返す
+func StorePath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.yml"), nil
+}
+
+// LoadStore は認証情報ファイルを読み込む。ファイルがない場合は空の Store を返す
+func LoadStore() (*Store, error) {
+	store := &Store{Hosts: map[string]HostCredential{}}
+
+	path, err := StorePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("認証情報ファイルの読み込みに失敗: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("認証情報ファイル %s の解析に失敗: %w", path, err)
+	}
+	if store.Hosts == nil {
+		store.Hosts = map[string]HostCredential{}
+	}
+	return store, nil
+}
+
+// fromStore は認証情報ファイルからトークンを読み込む
+func fromStore(host string) (string, error) {
+	store, err := LoadStore()
+	if err != nil {
+		return "", err
+	}
+	return store.Hosts[host].Token, nil
+}
+
+// Save は認証情報ファイルを所有者のみが読み書きできる権限（0600）で書き込む
+func (s *Store) Save() error {
+	path, err := StorePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("設定ディレクトリの作成に失敗: %w", err)
+	}
+
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("認証情報のエンコードに失敗: %w", err)
+	}
+
+	// 書き込み途中で中断しても既存のファイルを壊さないよう、一時ファイルに書いてから置き換える
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".credentials-*.yml")
+	if err != nil {
+		return fmt.Errorf("認証情報ファイルの書き込みに失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("認証情報ファイルの権限の設定に失敗: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("認証情報ファイルの書き込みに失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("認証情報ファイルの書き込みに失敗: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("認証情報ファイルの書き込みに失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 変更の前後に表示する行数
+const contextLines = 3
+
+// Op は行ごとの差分の種類
+type Op int
+
+const (
+	// Equal は変更のない行
+	Equal Op = iota
+	// Delete は削除された行
+	Delete
+	// Insert は追加された行
+	Insert
+)
+
+// Line は差分の1行
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines は2つのテキストの行単位の差分を最長共通部分列で求める
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// lcs[i][j] は x[i:] と y[j:] の最長共通部分列の長さ
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, Line{Equal, x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Delete, x[i]})
+			i++
+		default:
+			lines = append(lines, Line{Insert, y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, Line{Delete, x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, Line{Insert, y[j]})
+	}
+	return lines
+}
+
+// Unified は unified diff 形式の差分を返す。差分がなければ空文字列を返す
+func Unified(oldName, newName, a, b string) string {
+	lines := Lines(a, b)
+
+	var sb strings.Builder
+	for start := 0; start < len(lines); {
+		// 次の変更行を探す
+		first := start
+		for first < len(lines) && lines[first].Op == Equal {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		// 変更行の間が contextLines*2 以下であれば同じハンクにまとめる
+		last := first
+		for k := first; k < len(lines); k++ {
+			if lines[k].Op != Equal {
+				last = k
+			} else if k-last > contextLines*2 {
+				break
+			}
+		}
+
+		from := max(first-contextLines, start)
+		to := min(last+contextLines+1, len(lines))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&sb, lines, from, to)
+		start = to
+	}
+	return sb.String()
+}
+
+// writeHunk は lines[from:to] を1つのハンクとして書き出す
+func writeHunk(sb *strings.Builder, lines []Line, from, to int) {
+	// ハンク開始位置の行番号（1始まり）を求める
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.Op != Insert {
+			oldStart++
+		}
+		if l.Op != Delete {
+			newStart++
+		}
+	}
+
+	var oldCount, newCount int
+	for _, l := range lines[from:to] {
+		if l.Op != Insert {
+			oldCount++
+		}
+		if l.Op != Delete {
+			newCount++
+		}
+	}
+	// 空の範囲は直前の行番号で表す
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, l := range lines[from:to] {
+		prefix := " "
+		switch l.Op {
+		case Delete:
+			prefix = "-"
+		case Insert:
+			prefix = "+"
+		}
+		sb.WriteString(prefix + l.Text + "\n")
+	}
+}
+
+// split は末尾の改行を除いて行に分割する
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
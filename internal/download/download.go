@@ -2,19 +2,24 @@ package download
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/google/go-github/v60/github"
 	"github.com/hiroyannnn/ruleforge/internal/config"
-	"golang.org/x/oauth2"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/output"
 )
 
-// Execute はダウンロード処理を実行
-func Execute(cfg *config.Config) error {
+// Execute はダウンロード処理を実行し、ファイルごとの結果を返す（失敗した場合も途中までの結果を返す）
+func Execute(ctx context.Context, cfg *config.Config) (*output.Result, error) {
 	if cfg.Verbose {
 		log.Printf("ベースリポジトリ: %s からファイルをダウンロードします", cfg.BaseRepo)
 	}
@@ -22,122 +27,170 @@ func Execute(cfg *config.Config) error {
 	// GitHubクライアントの初期化
 	client, owner, repo, err := initGitHubClient(cfg)
 	if err != nil {
-		return fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+		res := output.NewResult("")
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
 	}
 
-	// ファイルをダウンロード
-	ctx := context.Background()
-	for _, filePath := range cfg.Files {
-		if cfg.Verbose {
-			log.Printf("ファイル '%s' をダウンロード中...", filePath)
-		}
+	src := &Source{Client: client, Owner: owner, Repo: repo}
+	return downloadFiles(ctx, src, cfg)
+}
+
+// fileResult は1ファイル分のダウンロード結果
+type fileResult struct {
+	remotePath string
+	localPath  string
+	content    []byte
+	notes      []string
+	warnings   []string
+	err        error
+}
 
-		// 汎用パスでまずダウンロードを試みる
-		downloadPath := filePath
-
-		// GitHubからファイルコンテンツを取得
-		content, _, _, err := client.Repositories.GetContents(
-			ctx,
-			owner,
-			repo,
-			downloadPath,
-			&github.RepositoryContentGetOptions{},
-		)
-		if err != nil && cfg.RepoName != "" {
-			// 汎用パスでエラーが発生した場合、リポジトリ固有のパスでリトライ
-			repoSpecificPath := filepath.Join(cfg.RepoName, filePath)
-			if cfg.Verbose {
-				log.Printf("汎用パスでファイルが見つかりません。リポジトリ固有のパス '%s' でリトライします", repoSpecificPath)
+// downloadFiles は対象ファイルをワーカープールで並列にダウンロードする
+// ログとエラーは並列実行の順序に関わらず cfg.Files の順に出力する
+func downloadFiles(ctx context.Context, src *Source, cfg *config.Config) (*output.Result, error) {
+	workers := cfg.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	results := make([]fileResult, len(cfg.Files))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, filePath := range cfg.Files {
+		wg.Add(1)
+		go func(i int, filePath string) {
+			defer wg.Done()
+
+			// ワーカーの空きを待つ（キャンセルされた場合は中断）
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = fileResult{err: fmt.Errorf("ファイル '%s' のダウンロードを中断: %w", filePath, ctx.Err())}
+				return
 			}
-			content, _, _, err = client.Repositories.GetContents(
-				ctx,
-				owner,
-				repo,
-				repoSpecificPath,
-				&github.RepositoryContentGetOptions{},
-			)
-			if err != nil {
-				return fmt.Errorf("ファイル '%s' および '%s' の取得に失敗: %w", filePath, repoSpecificPath, err)
+			defer func() { <-sem }()
+
+			results[i] = downloadFile(ctx, src, cfg, filePath)
+		}(i, filePath)
+	}
+	wg.Wait()
+
+	res := output.NewResult("")
+	var errs []error
+	downloaded := map[string]fileResult{}
+	for i, result := range results {
+		if cfg.Verbose {
+			log.Printf("ファイル '%s' をダウンロード中...", cfg.Files[i])
+			for _, note := range result.notes {
+				log.Print(note)
 			}
-			downloadPath = repoSpecificPath
-		} else if err != nil {
-			return fmt.Errorf("ファイル '%s' の取得に失敗: %w", downloadPath, err)
+		}
+		for _, warning := range result.warnings {
+			res.Warn("%s", warning)
 		}
 
-		// ファイルコンテンツをデコード
-		fileContent, err := content.GetContent()
-		if err != nil {
-			return fmt.Errorf("ファイル '%s' のコンテンツデコードに失敗: %w", filePath, err)
+		if result.err != nil {
+			log.Printf("エラー: %v", result.err)
+			errs = append(errs, result.err)
+			res.Files = append(res.Files, output.File{
+				Path:       filepath.Join(cfg.LocalDir, cfg.Files[i]),
+				RemotePath: result.remotePath,
+				Status:     output.StatusFailed,
+				Error:      result.err.Error(),
+			})
+			continue
 		}
 
-		// ローカルにファイルを書き込む
-		localFilePath := filepath.Join(cfg.LocalDir, filePath)
+		log.Printf("ファイル '%s' をダウンロードしました: %s", result.remotePath, result.localPath)
+		downloaded[cfg.Files[i]] = result
+		res.Files = append(res.Files, output.File{
+			Path:       result.localPath,
+			RemotePath: result.remotePath,
+			SHA:        gitutil.BlobSHA(result.content),
+			Status:     output.StatusDownloaded,
+		})
+	}
 
-		// ディレクトリが存在しない場合は作成
-		if err := os.MkdirAll(filepath.Dir(localFilePath), 0755); err != nil {
-			return fmt.Errorf("ディレクトリ '%s' の作成に失敗: %w", filepath.Dir(localFilePath), err)
-		}
+	// ダウンロードした内容をロックファイルに記録する
+	var lockErr error
+	if len(downloaded) > 0 {
+		lockErr = lockfile.Update(lockfile.Path(cfg), cfg.BaseRepo, func(lock *lockfile.Lockfile) {
+			for filePath, result := range downloaded {
+				lock.Set(filePath, result.remotePath, result.content, lockfile.SourceDownload)
+			}
+		})
+	}
 
-		// ファイルを書き込む
-		if err := os.WriteFile(localFilePath, []byte(fileContent), 0644); err != nil {
-			return fmt.Errorf("ファイル '%s' の書き込みに失敗: %w", localFilePath, err)
+	if len(errs) > 0 {
+		err := fmt.Errorf("%d/%d 件のファイルのダウンロードに失敗: %w", len(errs), len(cfg.Files), errors.Join(errs...))
+		if lockErr != nil {
+			err = errors.Join(err, lockErr)
 		}
-
-		log.Printf("ファイル '%s' をダウンロードしました: %s", downloadPath, localFilePath)
+		return res, res.Fail(err)
+	}
+	if lockErr != nil {
+		return res, res.Fail(lockErr)
 	}
 
 	log.Println("すべてのファイルのダウンロードが完了しました")
-	return nil
+	return res, nil
 }
 
-// initGitHubClient はGitHubクライアントを初期化し、所有者とリポジトリ名を抽出
-func initGitHubClient(cfg *config.Config) (*github.Client, string, string, error) {
-	// リポジトリURLからオーナーとリポジトリ名を抽出
-	owner, repo, err := parseRepoURL(cfg.BaseRepo)
+// downloadFile は1ファイルを取得してローカルに書き込む
+func downloadFile(ctx context.Context, src *Source, cfg *config.Config, filePath string) fileResult {
+	var result fileResult
+
+	// 汎用パス、リポジトリ固有のパスの順にファイルを取得
+	repoDirs := cfg.RepoDirs()
+	file, err := src.Resolve(ctx, repoDirs, filePath)
+	if len(repoDirs) > 0 && (err != nil || file.RemotePath != filePath) {
+		result.notes = append(result.notes, fmt.Sprintf("汎用パスでファイルが見つかりません。リポジトリ固有のパス '%s' でリトライします", path.Join(repoDirs[0], filePath)))
+	}
 	if err != nil {
-		return nil, "", "", err
+		result.err = err
+		return result
 	}
+	result.remotePath = file.RemotePath
 
-	var client *github.Client
-
-	// GitHubトークンが設定されている場合は認証付きクライアントを作成
-	if cfg.GitHubToken != "" {
-		ctx := context.Background()
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: cfg.GitHubToken},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
-	} else {
-		// 認証なしクライアント（レート制限に注意）
-		client = github.NewClient(nil)
+	// owner 形式で移行前の flat 形式のパスから取得した場合は移行を促す
+	if len(repoDirs) > 1 && file.RemotePath != filePath && file.RemotePath != path.Join(repoDirs[0], filePath) {
+		result.warnings = append(result.warnings, fmt.Sprintf("'%s' は移行前の配置から取得しました。ruleforge layout migrate で %s/ に移行できます", file.RemotePath, repoDirs[0]))
 	}
 
-	return client, owner, repo, nil
+	// ローカルにファイルを書き込む
+	localFilePath := filepath.Join(cfg.LocalDir, filePath)
+	result.localPath = localFilePath
+
+	// ディレクトリが存在しない場合は作成
+	if err := os.MkdirAll(filepath.Dir(localFilePath), 0755); err != nil {
+		result.err = fmt.Errorf("ディレクトリ '%s' の作成に失敗: %w", filepath.Dir(localFilePath), err)
+		return result
+	}
+
+	// ファイルを書き込む
+	if err := os.WriteFile(localFilePath, file.Content, 0644); err != nil {
+		result.err = fmt.Errorf("ファイル '%s' の書き込みに失敗: %w", localFilePath, err)
+		return result
+	}
+	result.content = file.Content
+
+	return result
 }
 
-// parseRepoURL はGitHubリポジトリURLから所有者とリポジトリ名を抽出
-func parseRepoURL(repoURL string) (string, string, error) {
-	// URLからgithub.comドメイン以降の部分を抽出
-	repoURL = strings.TrimSuffix(repoURL, "/")
-	repoURL = strings.TrimSuffix(repoURL, ".git")
-
-	var parts []string
-
-	// https://github.com/owner/repo 形式
-	if strings.HasPrefix(repoURL, "https://github.com/") {
-		parts = strings.Split(strings.TrimPrefix(repoURL, "https://github.com/"), "/")
-	} else if strings.HasPrefix(repoURL, "git@github.com:") {
-		// git@github.com:owner/repo.git 形式
-		parts = strings.Split(strings.TrimPrefix(repoURL, "git@github.com:"), "/")
-	} else {
-		// owner/repo 形式（短縮形）
-		parts = strings.Split(repoURL, "/")
+// initGitHubClient はGitHubクライアントを初期化し、所有者とリポジトリ名を抽出
+func initGitHubClient(cfg *config.Config) (*github.Client, string, string, error) {
+	// リポジトリURLからオーナーとリポジトリ名を抽出
+	owner, repo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	if len(parts) < 2 {
-		return "", "", fmt.Errorf("無効なリポジトリURL形式: %s", repoURL)
+	// 認証（トークン未設定時は認証なし、レート制限に注意）とリトライを組み込んだクライアントを作成
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	return parts[0], parts[1], nil
+	return client, owner, repo, nil
 }
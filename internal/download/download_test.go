@@ -1,13 +1,20 @@
 package download
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/google/go-github/v60/github"
 	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/output"
 )
 
 func TestExecute(t *testing.T) {
@@ -69,7 +76,7 @@ func TestExecute(t *testing.T) {
 
 	t.Skip("このテストはモックが正しく設定されていないためスキップします")
 
-	err = Execute(cfg)
+	_, err = Execute(context.Background(), cfg)
 	if err != nil {
 		t.Fatalf("ダウンロード処理に失敗: %v", err)
 	}
@@ -92,42 +99,84 @@ func TestExecute(t *testing.T) {
 	}
 }
 
-func TestParseRepoURL(t *testing.T) {
-	// 様々なURL形式を試験
-	testCases := []struct {
-		url      string
-		owner    string
-		repo     string
-		hasError bool
-	}{
-		{"https://github.com/owner/repo", "owner", "repo", false},
-		{"https://github.com/owner/repo.git", "owner", "repo", false},
-		{"https://github.com/owner/repo/", "owner", "repo", false},
-		{"git@github.com:owner/repo.git", "owner", "repo", false},
-		{"owner/repo", "owner", "repo", false},
-		{"", "", "", true},
-		{"invalid-url", "", "", true},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.url, func(t *testing.T) {
-			owner, repo, err := parseRepoURL(tc.url)
-
-			if tc.hasError {
-				if err == nil {
-					t.Errorf("エラーが期待されましたが、成功しました: %s, %s", owner, repo)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("予期しないエラー: %v", err)
-				}
-				if owner != tc.owner {
-					t.Errorf("owner: 期待値 %s, 実際の値 %s", tc.owner, owner)
-				}
-				if repo != tc.repo {
-					t.Errorf("repo: 期待値 %s, 実際の値 %s", tc.repo, repo)
-				}
-			}
-		})
+func TestDownloadFiles(t *testing.T) {
+	// 汎用パス、リポジトリ固有パス、存在しないファイルを返すモックサーバー
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/testowner/testrepo/contents/a.md":
+			_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "Z2VuZXJhbA=="}`))
+		case "/repos/testowner/testrepo/contents/myrepo/b.md":
+			_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "c3BlY2lmaWM="}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("URLの解析に失敗: %v", err)
+	}
+	client.BaseURL = baseURL
+
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		Files:       []string{"a.md", "missing.md", "b.md"},
+		LocalDir:    tempDir,
+		RepoName:    "myrepo",
+		Concurrency: 2,
+	}
+
+	res, err := downloadFiles(context.Background(), &Source{Client: client, Owner: "testowner", Repo: "testrepo"}, cfg)
+	if err == nil {
+		t.Fatalf("存在しないファイルのエラーが期待されましたが、成功しました")
+	}
+	if !strings.Contains(err.Error(), "1/3") || !strings.Contains(err.Error(), "missing.md") {
+		t.Errorf("エラーメッセージにファイルごとの失敗が含まれていません: %v", err)
+	}
+
+	// 失敗したファイルがあっても他のファイルはダウンロードされる
+	expected := map[string]string{"a.md": "general", "b.md": "specific"}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Errorf("ファイル '%s' がダウンロードされていません: %v", name, err)
+			continue
+		}
+		if string(content) != want {
+			t.Errorf("%s: 期待値 %q, 実際の値 %q", name, want, string(content))
+		}
+	}
+
+	// ファイルごとの結果を cfg.Files の順に返す
+	statuses := []string{output.StatusDownloaded, output.StatusFailed, output.StatusDownloaded}
+	if len(res.Files) != len(statuses) {
+		t.Fatalf("結果の件数: 期待値 %d, 実際の値 %d", len(statuses), len(res.Files))
+	}
+	for i, want := range statuses {
+		if res.Files[i].Status != want {
+			t.Errorf("%s: 期待値 %s, 実際の値 %s", cfg.Files[i], want, res.Files[i].Status)
+		}
+	}
+	if f := res.Files[2]; f.RemotePath != "myrepo/b.md" || f.SHA != gitutil.BlobSHA([]byte("specific")) {
+		t.Errorf("b.md の結果が期待と異なります: %+v", f)
+	}
+	if res.Error == "" {
+		t.Errorf("結果にエラーが記録されていません")
+	}
+
+	// ダウンロードしたファイルだけをロックファイルに記録する
+	lock, err := lockfile.Load(lockfile.Path(cfg))
+	if err != nil {
+		t.Fatalf("ロックファイルの読み込みに失敗: %v", err)
+	}
+	if len(lock.Files) != 2 || lock.Files["b.md"] == nil || lock.Files["b.md"].RemotePath != "myrepo/b.md" {
+		t.Errorf("ロックファイルの記録が期待と異なります: %+v", lock.Files)
+	}
+	if diverged, err := lock.Diverged(cfg); err != nil || len(diverged) != 0 {
+		t.Errorf("ダウンロード直後にロックファイルと異なるファイルがあります: %v, %v", diverged, err)
 	}
 }
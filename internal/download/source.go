@@ -0,0 +1,82 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// Source はベースリポジトリからルールファイルを取得する
+type Source struct {
+	Client *github.Client
+	Owner  string
+	Repo   string
+
+	// 取得するブランチまたはコミット（空の場合はデフォルトブランチ）
+	Ref string
+}
+
+// File はベースリポジトリから取得したルールファイル
+type File struct {
+	// 対象ファイルのパス（設定の target-files の要素）
+	Path string
+
+	// ベースリポジトリ上のパス（汎用パスまたはリポジトリ固有のパス）
+	RemotePath string
+
+	// ファイルの内容
+	Content []byte
+}
+
+// Resolve は download が取得するのと同じ規則でファイルを取得する
+// 汎用パスを優先し、見つからない場合は repoDirs のディレクトリ配下のリポジトリ固有パスを順に探す
+func (s *Source) Resolve(ctx context.Context, repoDirs []string, filePath string) (*File, error) {
+	content, err := s.get(ctx, filePath)
+	remotePath := filePath
+	if err != nil && len(repoDirs) > 0 {
+		// 汎用パスでエラーが発生した場合、リポジトリ固有のパスでリトライ
+		tried := []string{filePath}
+		for _, dir := range repoDirs {
+			repoSpecificPath := path.Join(dir, filePath)
+			tried = append(tried, repoSpecificPath)
+			if content, err = s.get(ctx, repoSpecificPath); err == nil {
+				remotePath = repoSpecificPath
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", strings.Join(tried, "' および '"), err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", filePath, err)
+	}
+
+	// ファイルコンテンツをデコード
+	decoded, err := content.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("ファイル '%s' のコンテンツデコードに失敗: %w", filePath, err)
+	}
+
+	return &File{Path: filePath, RemotePath: remotePath, Content: []byte(decoded)}, nil
+}
+
+// get はGitHubからファイルコンテンツを取得する
+func (s *Source) get(ctx context.Context, remotePath string) (*github.RepositoryContent, error) {
+	content, _, _, err := s.Client.Repositories.GetContents(
+		ctx,
+		s.Owner,
+		s.Repo,
+		remotePath,
+		&github.RepositoryContentGetOptions{Ref: s.Ref},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("'%s' はファイルではありません", remotePath)
+	}
+	return content, nil
+}
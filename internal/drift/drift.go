@@ -0,0 +1,166 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+)
+
+// ファイルおよびリポジトリの状態
+const (
+	// ベースリポジトリの内容と一致している
+	StateUpToDate = "up-to-date"
+	// ベースリポジトリの内容と異なる
+	StateDrifted = "drifted"
+	// ファイルが存在しない
+	StateMissing = "missing"
+	// 比較できなかった（リポジトリにアクセスできないなど）
+	StateError = "error"
+)
+
+// FileStatus は1ファイル分の比較結果
+type FileStatus struct {
+	// 対象ファイルのパス
+	Path string `json:"path"`
+
+	// 期待される内容を取得したベースリポジトリ上のパス
+	RemotePath string `json:"remote-path"`
+
+	// 比較結果
+	State string `json:"status"`
+
+	// ベースリポジトリの内容（download が書き込む内容）
+	Expected []byte `json:"-"`
+}
+
+// Resolver は download と同じ規則で期待されるファイルを取得する
+// 汎用パスで取得できたファイルは全リポジトリで共通のためキャッシュする
+type Resolver struct {
+	Source *download.Source
+
+	// ベースリポジトリでのリポジトリ固有のルールの配置（config.LayoutFlat または config.LayoutOwner）
+	Layout string
+
+	mu      sync.Mutex
+	general map[string]*download.File
+}
+
+// NewResolver は Resolver を生成する
+func NewResolver(src *download.Source, layout string) *Resolver {
+	return &Resolver{Source: src, Layout: layout, general: map[string]*download.File{}}
+}
+
+// Resolve は owner/repo のリポジトリで download が取得するファイルを返す
+func (r *Resolver) Resolve(ctx context.Context, owner, repo, filePath string) (*download.File, error) {
+	r.mu.Lock()
+	f, ok := r.general[filePath]
+	r.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+
+	f, err := r.Source.Resolve(ctx, config.RepoDirs(r.Layout, owner, repo), filePath)
+	if err != nil {
+		return nil, err
+	}
+	if f.RemotePath == filePath {
+		r.mu.Lock()
+		r.general[filePath] = f
+		r.mu.Unlock()
+	}
+	return f, nil
+}
+
+// CompareRepo は利用側リポジトリのデフォルトブランチにある対象ファイルを、
+// download が生成する内容と比較する
+func CompareRepo(ctx context.Context, client *github.Client, r *Resolver, owner, repo string, files []string) ([]FileStatus, error) {
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("リポジトリ情報の取得に失敗: %w", err)
+	}
+
+	statuses := make([]FileStatus, 0, len(files))
+	for _, filePath := range files {
+		expected, err := r.Resolve(ctx, owner, repo, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		status := FileStatus{Path: filePath, RemotePath: expected.RemotePath, Expected: expected.Content}
+
+		current, _, _, err := client.Repositories.GetContents(ctx, owner, repo, filePath,
+			&github.RepositoryContentGetOptions{Ref: repository.GetDefaultBranch()})
+		switch {
+		case ghclient.IsNotFound(err) || (err == nil && current == nil):
+			status.State = StateMissing
+		case err != nil:
+			return nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", filePath, err)
+		default:
+			content, err := current.GetContent()
+			if err != nil {
+				return nil, fmt.Errorf("ファイル '%s' のコンテンツデコードに失敗: %w", filePath, err)
+			}
+			status.State = StateDrifted
+			if bytes.Equal([]byte(content), expected.Content) {
+				status.State = StateUpToDate
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// CompareLocal はローカルの対象ファイルを、download がベースリポジトリから取得して書き込む内容と比較する
+// ローカルのファイルは変更しない
+func CompareLocal(ctx context.Context, src *download.Source, cfg *config.Config) ([]FileStatus, error) {
+	repoDirs := cfg.RepoDirs()
+	statuses := make([]FileStatus, 0, len(cfg.Files))
+	for _, filePath := range cfg.Files {
+		expected, err := src.Resolve(ctx, repoDirs, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		status := FileStatus{Path: filePath, RemotePath: expected.RemotePath, Expected: expected.Content}
+		local, err := os.ReadFile(filepath.Join(cfg.LocalDir, filePath))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			status.State = StateMissing
+		case err != nil:
+			return nil, fmt.Errorf("ファイル '%s' の読み込みに失敗: %w", filePath, err)
+		case bytes.Equal(local, expected.Content):
+			status.State = StateUpToDate
+		default:
+			status.State = StateDrifted
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Summarize はファイルごとの結果からリポジトリ全体の状態を決める
+// 内容の異なるファイルがあれば drifted、なければ存在しないファイルがあれば missing とする
+func Summarize(statuses []FileStatus) string {
+	state := StateUpToDate
+	for _, s := range statuses {
+		switch s.State {
+		case StateDrifted:
+			return StateDrifted
+		case StateMissing:
+			state = StateMissing
+		}
+	}
+	return state
+}
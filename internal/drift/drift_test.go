@@ -0,0 +1,189 @@
+package drift
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+)
+
+// newContentsServer はパスごとのファイル内容を返すモックサーバーを作成
+// files のキーは "owner/repo/path" 形式
+func newContentsServer(t *testing.T, files map[string]string) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := strings.TrimPrefix(r.URL.Path, "/repos/")
+		if strings.Count(path, "/") == 1 {
+			_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+			return
+		}
+
+		parts := strings.SplitN(path, "/", 4)
+		content, ok := files[parts[0]+"/"+parts[1]+"/"+parts[3]]
+		if len(parts) != 4 || parts[2] != "contents" || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestCompareRepo(t *testing.T) {
+	client := newContentsServer(t, map[string]string{
+		"org/rules/a.md":     "general a",
+		"org/rules/svc/b.md": "specific b",
+		"org/rules/c.md":     "general c",
+		"org/svc/a.md":       "general a",
+		"org/svc/b.md":       "old b",
+	})
+
+	resolver := NewResolver(&download.Source{Client: client, Owner: "org", Repo: "rules"}, config.LayoutFlat)
+	statuses, err := CompareRepo(context.Background(), client, resolver, "org", "svc", []string{"a.md", "b.md", "c.md"})
+	if err != nil {
+		t.Fatalf("比較に失敗: %v", err)
+	}
+
+	expected := []struct {
+		path       string
+		remotePath string
+		state      string
+	}{
+		{"a.md", "a.md", StateUpToDate},
+		{"b.md", "svc/b.md", StateDrifted},
+		{"c.md", "c.md", StateMissing},
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("結果の件数: 期待値 %d, 実際の値 %d", len(expected), len(statuses))
+	}
+	for i, want := range expected {
+		got := statuses[i]
+		if got.Path != want.path || got.RemotePath != want.remotePath || got.State != want.state {
+			t.Errorf("%d: 期待値 %+v, 実際の値 {%s %s %s}", i, want, got.Path, got.RemotePath, got.State)
+		}
+	}
+	if string(statuses[1].Expected) != "specific b" {
+		t.Errorf("期待される内容が一致しません: %q", statuses[1].Expected)
+	}
+
+	if got := Summarize(statuses); got != StateDrifted {
+		t.Errorf("Summarize: 期待値 %s, 実際の値 %s", StateDrifted, got)
+	}
+}
+
+func TestResolveOwnerLayout(t *testing.T) {
+	client := newContentsServer(t, map[string]string{
+		"org/rules/org-a/api/a.md": "org-a a",
+		"org/rules/api/a.md":       "flat a",
+		"org/rules/api/b.md":       "flat b",
+	})
+	resolver := NewResolver(&download.Source{Client: client, Owner: "org", Repo: "rules"}, config.LayoutOwner)
+
+	// 所有者付きのディレクトリを優先し、なければ移行前の flat 形式のディレクトリを使う
+	expected := []struct {
+		owner, path, remotePath string
+	}{
+		{"org-a", "a.md", "org-a/api/a.md"},
+		{"org-b", "a.md", "api/a.md"},
+		{"org-a", "b.md", "api/b.md"},
+	}
+	for _, want := range expected {
+		f, err := resolver.Resolve(context.Background(), want.owner, "api", want.path)
+		if err != nil {
+			t.Fatalf("%s/api の %s の取得に失敗: %v", want.owner, want.path, err)
+		}
+		if f.RemotePath != want.remotePath {
+			t.Errorf("%s/api の %s: 期待値 %s, 実際の値 %s", want.owner, want.path, want.remotePath, f.RemotePath)
+		}
+	}
+}
+
+func TestCompareLocal(t *testing.T) {
+	client := newContentsServer(t, map[string]string{
+		"org/rules/a.md":                   "general a",
+		"org/rules/mono/services/api/b.md": "specific b",
+		"org/rules/c.md":                   "general c",
+	})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("general a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.md"), []byte("local b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		RepoName:    "mono",
+		ProjectPath: "services/api",
+		LocalDir:    dir,
+		Files:       []string{"a.md", "b.md", "c.md"},
+	}
+	statuses, err := CompareLocal(context.Background(), &download.Source{Client: client, Owner: "org", Repo: "rules"}, cfg)
+	if err != nil {
+		t.Fatalf("比較に失敗: %v", err)
+	}
+
+	expected := []struct {
+		path, remotePath, state string
+	}{
+		{"a.md", "a.md", StateUpToDate},
+		{"b.md", "mono/services/api/b.md", StateDrifted},
+		{"c.md", "c.md", StateMissing},
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("結果の件数: 期待値 %d, 実際の値 %d", len(expected), len(statuses))
+	}
+	for i, want := range expected {
+		got := statuses[i]
+		if got.Path != want.path || got.RemotePath != want.remotePath || got.State != want.state {
+			t.Errorf("%d: 期待値 %+v, 実際の値 {%s %s %s}", i, want, got.Path, got.RemotePath, got.State)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		states   []string
+		expected string
+	}{
+		{"すべて一致", []string{StateUpToDate, StateUpToDate}, StateUpToDate},
+		{"存在しないファイルのみ", []string{StateUpToDate, StateMissing}, StateMissing},
+		{"差分があれば drifted", []string{StateMissing, StateDrifted}, StateDrifted},
+		{"対象ファイルなし", nil, StateUpToDate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var statuses []FileStatus
+			for _, s := range tc.states {
+				statuses = append(statuses, FileStatus{State: s})
+			}
+			if got := Summarize(statuses); got != tc.expected {
+				t.Errorf("期待値 %s, 実際の値 %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/publish"
+)
+
+// Options は fleet sync の実行オプション
+type Options struct {
+	// 状態ファイルを無視して最初から同期する
+	Restart bool
+
+	// プルリクエストを作成せず、同期が必要なリポジトリを表示するだけにする
+	DryRun bool
+}
+
+// Sync はベースリポジトリのルールを利用側リポジトリに配布する
+// 各リポジトリでブランチとPRを作成し、既に最新のリポジトリはスキップする
+// 戻り値は配布先リポジトリごとの結果（Project に配布先リポジトリを設定する）
+func Sync(ctx context.Context, cfg *config.Config, opts Options) ([]*output.Result, error) {
+	if !cfg.HasCredentials() {
+		return nil, fmt.Errorf("GitHub APIトークンが設定されていません。環境変数 GITHUB_TOKEN を設定するか、設定ファイルで指定してください")
+	}
+
+	if len(cfg.Fleet.Repos) == 0 {
+		return nil, fmt.Errorf("配布先リポジトリが指定されていません。--repos フラグまたは設定ファイルの fleet.repos で指定してください")
+	}
+
+	baseOwner, baseRepo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+
+	return run(ctx, client, baseOwner, baseRepo, cfg, opts)
+}
+
+// run は初期化済みのクライアントで同期処理を行う
+func run(ctx context.Context, client *github.Client, baseOwner, baseRepo string, cfg *config.Config, opts Options) ([]*output.Result, error) {
+	// 同期中にベースリポジトリが更新されても内容が揃うよう、最新コミットに固定する
+	repository, _, err := client.Repositories.Get(ctx, baseOwner, baseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("ベースリポジトリ情報の取得に失敗: %w", err)
+	}
+	baseRef, _, err := client.Git.GetRef(ctx, baseOwner, baseRepo, "refs/heads/"+repository.GetDefaultBranch())
+	if err != nil {
+		return nil, fmt.Errorf("ベースブランチのリファレンス取得に失敗: %w", err)
+	}
+	baseSHA := baseRef.GetObject().GetSHA()
+
+	// 状態ファイルを読み込む（ドライランでは読み書きしない）
+	stateFile := cfg.Fleet.StateFile
+	if opts.DryRun {
+		stateFile = ""
+	}
+	loadFrom := stateFile
+	if opts.Restart {
+		loadFrom = ""
+	}
+	state, err := loadState(loadFrom, cfg.BaseRepo, baseSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syncer{
+		client:   client,
+		cfg:      cfg,
+		resolver: drift.NewResolver(&download.Source{Client: client, Owner: baseOwner, Repo: baseRepo, Ref: baseSHA}, cfg.Layout),
+		baseName: baseOwner + "/" + baseRepo,
+	}
+
+	counts := map[string]int{}
+	var results []*output.Result
+	for _, target := range cfg.Fleet.Repos {
+		if err := ctx.Err(); err != nil {
+			return results, fmt.Errorf("同期を中断しました（再実行すると状態ファイル '%s' から再開します）: %w", stateFile, err)
+		}
+
+		if prev := state.Repos[target]; prev.Done() {
+			log.Printf("%s: 前回の実行で処理済みのためスキップします（%s）", target, prev.Status)
+			counts[prev.Status]++
+			res := output.NewResult(target)
+			if prev.PRURL != "" {
+				res.PullRequest = &output.PullRequest{URL: prev.PRURL, Existed: true}
+			}
+			results = append(results, res)
+			continue
+		}
+
+		res := output.NewResult(target)
+		results = append(results, res)
+		rs := s.syncRepo(ctx, target, opts.DryRun, res)
+		counts[rs.Status]++
+		if opts.DryRun {
+			continue
+		}
+
+		state.Repos[target] = rs
+		if err := state.save(stateFile); err != nil {
+			return results, err
+		}
+	}
+
+	log.Printf("同期結果: 同期 %d 件, 最新 %d 件, 失敗 %d 件", counts[StatusSynced], counts[StatusUpToDate], counts[StatusFailed])
+
+	if counts[StatusFailed] > 0 {
+		return results, fmt.Errorf("%d 件のリポジトリで同期に失敗しました（再実行すると失敗したリポジトリのみ再試行します）", counts[StatusFailed])
+	}
+	return results, nil
+}
+
+// syncer は利用側リポジトリ1件ずつの同期処理を行う
+type syncer struct {
+	client   *github.Client
+	cfg      *config.Config
+	resolver *drift.Resolver
+	baseName string
+}
+
+// syncRepo は1リポジトリを同期し、結果を返す
+// ファイル、ブランチ、プルリクエストは res に記録する
+func (s *syncer) syncRepo(ctx context.Context, target string, dryRun bool, res *output.Result) *RepoState {
+	rs := &RepoState{UpdatedAt: time.Now()}
+
+	status, prURL, err := s.sync(ctx, target, dryRun, res)
+	if err != nil {
+		log.Printf("%s: 同期に失敗: %v", target, err)
+		rs.Status = StatusFailed
+		rs.Error = res.Fail(err).Error()
+		return rs
+	}
+
+	rs.Status = status
+	rs.PRURL = prURL
+	return rs
+}
+
+// sync は利用側リポジトリのファイルを比較し、差分があればPRを作成する
+func (s *syncer) sync(ctx context.Context, target string, dryRun bool, res *output.Result) (string, string, error) {
+	owner, repo, err := ghclient.ParseRepoURL(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 差分のあるファイルを収集
+	statuses, err := drift.CompareRepo(ctx, s.client, s.resolver, owner, repo, s.cfg.Files)
+	if err != nil {
+		return "", "", err
+	}
+
+	var files []publish.File
+	for _, status := range statuses {
+		if status.State == drift.StateUpToDate {
+			continue
+		}
+		files = append(files, publish.File{
+			Path:    status.Path,
+			Content: status.Expected,
+			Source:  s.baseName + "/" + status.RemotePath,
+		})
+	}
+
+	if len(files) == 0 {
+		log.Printf("%s: 最新のルールと一致しているためスキップします", target)
+		return StatusUpToDate, "", nil
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+
+	if dryRun {
+		log.Printf("%s: 同期が必要なファイル: %s", target, strings.Join(paths, ", "))
+		for _, status := range statuses {
+			if status.State != drift.StateUpToDate {
+				res.Files = append(res.Files, output.File{RemotePath: status.Path, Status: status.State})
+			}
+		}
+		return StatusSynced, "", nil
+	}
+
+	message := s.cfg.Message
+	if message == "" {
+		message = fmt.Sprintf("Sync agent rules from %s", s.baseName)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "このPRは %s から ruleforge fleet sync により自動生成されました。\n\n", s.baseName)
+	body.WriteString("以下のAIエージェントルールを最新の内容に同期します。\n\n")
+	for _, p := range paths {
+		fmt.Fprintf(&body, "- `%s`\n", p)
+	}
+
+	result, err := publish.Run(ctx, s.client, &publish.Request{
+		Owner:       owner,
+		Repo:        repo,
+		Branch:      s.cfg.Fleet.BranchName,
+		Files:       files,
+		Message:     message,
+		Title:       message,
+		Body:        body.String(),
+		PullRequest: s.cfg.PullRequest,
+		Verbose:     s.cfg.Verbose,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// Source はベースリポジトリ上のパスでローカルのパスではないため記録しない
+	for i := range files {
+		files[i].Source = ""
+	}
+	result.Record(res, files)
+	return StatusSynced, result.PRURL, nil
+}
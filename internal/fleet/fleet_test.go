@@ -0,0 +1,222 @@
+package fleet
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// fakeGitHub はリポジトリ情報とファイル内容だけを扱う簡易的なGitHub APIのモック
+type fakeGitHub struct {
+	mu       sync.Mutex
+	repos    map[string]bool   // "owner/repo"
+	files    map[string]string // "owner/repo/path" -> 内容
+	requests []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/repos/"), "/", 4)
+	if len(parts) < 2 || !f.repos[parts[0]+"/"+parts[1]] {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		return
+	}
+	repo := parts[0] + "/" + parts[1]
+
+	switch {
+	case len(parts) == 2 && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+
+	case len(parts) == 4 && parts[2] == "git" && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`))
+
+	case len(parts) == 4 && parts[2] == "git" && r.Method == "POST":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/branch", "object": {"sha": "basesha"}}`))
+
+	case len(parts) == 4 && parts[2] == "contents" && r.Method == "GET":
+		content, ok := f.files[repo+"/"+parts[3]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case len(parts) == 4 && parts[2] == "contents" && r.Method == "PUT":
+		var body struct {
+			Content []byte `json:"content"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		f.files[repo+"/"+parts[3]] = string(body.Content)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"content": {}}`))
+
+	case len(parts) == 3 && parts[2] == "pulls" && r.Method == "POST":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 1, "html_url": "https://github.com/` + repo + `/pull/1"}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+func (f *fakeGitHub) requested(prefix string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("URLの解析に失敗: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestRun(t *testing.T) {
+	fake := &fakeGitHub{
+		repos: map[string]bool{"org/rules": true, "org/current": true, "org/stale": true},
+		files: map[string]string{
+			"org/rules/rules.md":   "v2",
+			"org/current/rules.md": "v2",
+			"org/stale/rules.md":   "v1",
+		},
+	}
+	client := newTestClient(t, fake)
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	cfg := &config.Config{
+		BaseRepo: "org/rules",
+		Files:    []string{"rules.md"},
+		Fleet: config.FleetConfig{
+			Repos:      []string{"org/current", "org/stale", "org/missing"},
+			BranchName: "ruleforge/sync-rules",
+			StateFile:  stateFile,
+		},
+	}
+
+	// org/missing が存在しないため失敗として報告される
+	results, err := run(context.Background(), client, "org", "rules", cfg, Options{})
+	if err == nil || !strings.Contains(err.Error(), "1 件") {
+		t.Fatalf("1件の失敗が期待されましたが、異なる結果でした: %v", err)
+	}
+
+	// 配布先リポジトリごとの結果を返す
+	if len(results) != 3 {
+		t.Fatalf("3件の結果が期待されました: %+v", results)
+	}
+	if stale := results[1]; stale.Project != "org/stale" || stale.PullRequest == nil || stale.PullRequest.Number != 1 ||
+		len(stale.Files) != 1 || stale.Files[0].RemotePath != "rules.md" || stale.Files[0].Status != output.StatusCommitted {
+		t.Errorf("同期したリポジトリの結果が期待と異なります: %+v", stale)
+	}
+	if results[2].Error == "" || len(results[0].Files) != 0 {
+		t.Errorf("失敗したリポジトリまたは最新のリポジトリの結果が期待と異なります: %+v, %+v", results[2], results[0])
+	}
+
+	if got := fake.files["org/stale/rules.md"]; got != "v2" {
+		t.Errorf("古いリポジトリが同期されていません: %q", got)
+	}
+	if fake.requested("POST /repos/org/current/pulls") {
+		t.Errorf("最新のリポジトリにPRが作成されました")
+	}
+
+	state, err := loadState(stateFile, "org/rules", "basesha")
+	if err != nil {
+		t.Fatalf("状態ファイルの読み込みに失敗: %v", err)
+	}
+	expected := map[string]string{
+		"org/current": StatusUpToDate,
+		"org/stale":   StatusSynced,
+		"org/missing": StatusFailed,
+	}
+	for repo, want := range expected {
+		if got := state.Repos[repo]; got == nil || got.Status != want {
+			t.Errorf("%s の状態: 期待値 %s, 実際の値 %+v", repo, want, got)
+		}
+	}
+	if state.Repos["org/stale"].PRURL != "https://github.com/org/stale/pull/1" {
+		t.Errorf("PR URLが記録されていません: %+v", state.Repos["org/stale"])
+	}
+
+	// 再実行時は失敗したリポジトリのみ処理される
+	fake.repos["org/missing"] = true
+	fake.requests = nil
+	if _, err := run(context.Background(), client, "org", "rules", cfg, Options{}); err != nil {
+		t.Fatalf("再実行に失敗: %v", err)
+	}
+	if fake.requested("GET /repos/org/stale") || fake.requested("GET /repos/org/current") {
+		t.Errorf("処理済みのリポジトリが再処理されました: %v", fake.requests)
+	}
+	if !fake.requested("POST /repos/org/missing/pulls") {
+		t.Errorf("失敗したリポジトリが再試行されていません: %v", fake.requests)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	fake := &fakeGitHub{
+		repos: map[string]bool{"org/rules": true, "org/stale": true},
+		files: map[string]string{
+			"org/rules/rules.md": "v2",
+			"org/stale/rules.md": "v1",
+		},
+	}
+	client := newTestClient(t, fake)
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	cfg := &config.Config{
+		BaseRepo: "org/rules",
+		Files:    []string{"rules.md"},
+		Fleet: config.FleetConfig{
+			Repos:      []string{"org/stale"},
+			BranchName: "ruleforge/sync-rules",
+			StateFile:  stateFile,
+		},
+	}
+
+	if _, err := run(context.Background(), client, "org", "rules", cfg, Options{DryRun: true}); err != nil {
+		t.Fatalf("ドライランに失敗: %v", err)
+	}
+	if fake.requested("PUT ") || fake.requested("POST ") {
+		t.Errorf("ドライランで変更系のリクエストが送信されました: %v", fake.requests)
+	}
+	if matches, _ := filepath.Glob(stateFile); len(matches) != 0 {
+		t.Errorf("ドライランで状態ファイルが作成されました")
+	}
+}
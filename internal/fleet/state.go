@@ -0,0 +1,105 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// リポジトリごとの同期状態
+const (
+	// プルリクエストを作成（または既存PRを更新）した
+	StatusSynced = "synced"
+	// 既に最新のルールと一致している
+	StatusUpToDate = "up-to-date"
+	// 同期に失敗した
+	StatusFailed = "failed"
+)
+
+// State は fleet sync の進捗を記録する状態ファイルの内容
+type State struct {
+	// 同期元のベースリポジトリ
+	BaseRepo string `json:"base-repo"`
+
+	// 同期元のコミットSHA（ベースリポジトリが更新された場合は状態をリセットする）
+	BaseSHA string `json:"base-sha"`
+
+	// リポジトリごとの結果
+	Repos map[string]*RepoState `json:"repos"`
+}
+
+// RepoState は1リポジトリ分の同期結果
+type RepoState struct {
+	Status    string    `json:"status"`
+	PRURL     string    `json:"pr-url,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated-at"`
+}
+
+// Done は再開時にスキップしてよい状態かどうかを返す
+func (s *RepoState) Done() bool {
+	return s != nil && (s.Status == StatusSynced || s.Status == StatusUpToDate)
+}
+
+// loadState は状態ファイルを読み込む
+// ファイルが存在しない場合や、同期元が異なる場合は空の状態を返す
+func loadState(path, baseRepo, baseSHA string) (*State, error) {
+	fresh := &State{BaseRepo: baseRepo, BaseSHA: baseSHA, Repos: map[string]*RepoState{}}
+	if path == "" {
+		return fresh, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("状態ファイル '%s' の読み込みに失敗: %w", path, err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("状態ファイル '%s' の解析に失敗: %w", path, err)
+	}
+
+	if state.BaseRepo != baseRepo || state.BaseSHA != baseSHA {
+		return fresh, nil
+	}
+	if state.Repos == nil {
+		state.Repos = map[string]*RepoState{}
+	}
+	return &state, nil
+}
+
+// save は状態ファイルを書き込む（書き込み途中で中断されても壊れないよう一時ファイル経由で置き換える）
+func (s *State) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("状態の変換に失敗: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("状態ファイルの作成に失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("状態ファイルの書き込みに失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("状態ファイルの書き込みに失敗: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("状態ファイル '%s' の更新に失敗: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package fleet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateSaveAndLoad(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := loadState(stateFile, "org/rules", "sha1")
+	if err != nil {
+		t.Fatalf("存在しない状態ファイルの読み込みに失敗: %v", err)
+	}
+	if len(state.Repos) != 0 {
+		t.Fatalf("空の状態が期待されましたが、%d 件の記録がありました", len(state.Repos))
+	}
+
+	state.Repos["org/a"] = &RepoState{Status: StatusSynced, PRURL: "https://github.com/org/a/pull/1", UpdatedAt: time.Now()}
+	state.Repos["org/b"] = &RepoState{Status: StatusFailed, Error: "boom", UpdatedAt: time.Now()}
+	if err := state.save(stateFile); err != nil {
+		t.Fatalf("状態ファイルの書き込みに失敗: %v", err)
+	}
+
+	loaded, err := loadState(stateFile, "org/rules", "sha1")
+	if err != nil {
+		t.Fatalf("状態ファイルの読み込みに失敗: %v", err)
+	}
+	if !loaded.Repos["org/a"].Done() {
+		t.Errorf("同期済みのリポジトリが処理済みとして扱われません: %+v", loaded.Repos["org/a"])
+	}
+	if loaded.Repos["org/b"].Done() {
+		t.Errorf("失敗したリポジトリが処理済みとして扱われました: %+v", loaded.Repos["org/b"])
+	}
+
+	// ベースリポジトリが更新された場合は状態をリセットする
+	reset, err := loadState(stateFile, "org/rules", "sha2")
+	if err != nil {
+		t.Fatalf("状態ファイルの読み込みに失敗: %v", err)
+	}
+	if len(reset.Repos) != 0 {
+		t.Errorf("ベースリポジトリ更新後も前回の状態が残っています: %v", reset.Repos)
+	}
+
+	// 一時ファイルが残っていないことを確認
+	entries, err := os.ReadDir(filepath.Dir(stateFile))
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("状態ファイル以外のファイルが残っています: %v", entries)
+	}
+}
@@ -0,0 +1,200 @@
+package ghclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// GitHub App のJWTの有効期間（GitHubの上限は10分）
+const appJWTLifetime = 9 * time.Minute
+
+// installationTokenSource は GitHub App のJWTをインストールトークンに交換する
+type installationTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+
+	// トークン発行APIの呼び出しに使うトランスポートとAPIのURL
+	base   http.RoundTripper
+	apiURL string
+
+	now func() time.Time
+}
+
+// appTransport はインストールトークンで認証してリクエストを送る http.RoundTripper
+// トークンは有効期限が切れたときだけ、そのときのリクエストのコンテキストで再発行する
+// oauth2.Transport と異なり、トークンの発行も --timeout や中断で打ち切られる
+type appTransport struct {
+	src  *installationTokenSource
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// newAppTransport は GitHub App の設定からインストールトークンで認証するトランスポートを生成する
+func newAppTransport(app config.AppConfig, apiURL string, base http.RoundTripper) (http.RoundTripper, error) {
+	if app.InstallationID == 0 {
+		return nil, fmt.Errorf("GitHub App のインストールID（auth.app.installation-id）が設定されていません")
+	}
+	if app.PrivateKeyFile == "" {
+		return nil, fmt.Errorf("GitHub App の秘密鍵ファイル（auth.app.private-key-file）が設定されていません")
+	}
+
+	key, err := loadPrivateKey(app.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &installationTokenSource{
+		appID:          app.AppID,
+		installationID: app.InstallationID,
+		key:            key,
+		base:           base,
+		apiURL:         apiURL,
+		now:            time.Now,
+	}
+	return &appTransport{src: src, base: base}, nil
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.currentToken(req.Context())
+	if err != nil {
+		// RoundTripper はエラーの場合もリクエストのボディを閉じる
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	token.SetAuthHeader(req)
+	return t.base.RoundTrip(req)
+}
+
+// currentToken は有効なトークンを返し、有効期限が近い場合は再発行する
+func (t *appTransport) currentToken(ctx context.Context) (*oauth2.Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token.Valid() {
+		return t.token, nil
+	}
+	token, err := t.src.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.token = token
+	return token, nil
+}
+
+// Token はJWTを発行し、インストールトークンと交換する
+func (s *installationTokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	jwt, err := signAppJWT(s.appID, s.key, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	// JWTは "Authorization: Bearer" ヘッダーで送る
+	client, err := newGitHubClient(&http.Client{Transport: &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt}),
+		Base:   s.base,
+	}}, s.apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token, _, err := client.Apps.CreateInstallationToken(ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App のインストールトークンの取得に失敗: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "Bearer",
+		Expiry:      token.GetExpiresAt().Time,
+	}, nil
+}
+
+// signAppJWT は GitHub App として認証するためのRS256のJWTを生成する
+func signAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// 時刻のずれを考慮して発行時刻を60秒前にする
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("JWTの署名に失敗: %w", err)
+	}
+
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
+
+// loadPrivateKey はPEM形式（PKCS#1 または PKCS#8）のRSA秘密鍵を読み込む
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App の秘密鍵ファイルの読み込みに失敗: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App の秘密鍵ファイル %s がPEM形式ではありません", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App の秘密鍵の解析に失敗: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App の秘密鍵がRSA鍵ではありません")
+	}
+	return key, nil
+}
+
+// newGitHubClient はAPIのURLを指定してGitHubクライアントを生成する
+func newGitHubClient(httpClient *http.Client, apiURL string) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if apiURL == "" {
+		return client, nil
+	}
+
+	if err := SetBaseURL(client, apiURL); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,205 @@
+package ghclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+// fakeAppServer はインストールトークンの発行とリポジトリ情報の取得を扱うモックサーバー
+type fakeAppServer struct {
+	t        *testing.T
+	key      *rsa.PublicKey
+	lifetime time.Duration
+
+	mu     sync.Mutex
+	issued int
+}
+
+func (f *fakeAppServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/app/installations/42/access_tokens":
+		if err := verifyJWT(auth, f.key, 7); err != nil {
+			f.t.Errorf("JWTが不正です: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.issued++
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"token":      fmt.Sprintf("ghs_%d", f.issued),
+			"expires_at": time.Now().Add(f.lifetime).UTC().Format(time.RFC3339),
+		})
+
+	case r.Method == "GET" && r.URL.Path == "/repos/org/rules":
+		if auth != fmt.Sprintf("ghs_%d", f.issued) {
+			f.t.Errorf("最新のインストールトークンが使われていません: %s", auth)
+		}
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// verifyJWT はRS256の署名と iss クレームを検証する
+func verifyJWT(token string, key *rsa.PublicKey, appID int64) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("JWTの形式ではありません: %s", token)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss int64 `json:"iss"`
+		Iat int64 `json:"iat"`
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != appID || claims.Exp-claims.Iat > int64((10*time.Minute).Seconds()) {
+		return fmt.Errorf("クレームが不正です: %+v", claims)
+	}
+	return nil
+}
+
+func newAppConfig(t *testing.T, fake *fakeAppServer) *config.Config {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("鍵の生成に失敗: %v", err)
+	}
+	fake.key = &key.PublicKey
+
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, data, 0600); err != nil {
+		t.Fatalf("鍵ファイルの書き込みに失敗: %v", err)
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return &config.Config{Auth: config.AuthConfig{
+		APIURL: server.URL,
+		App:    config.AppConfig{AppID: 7, InstallationID: 42, PrivateKeyFile: keyFile},
+	}}
+}
+
+func TestAppInstallationToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		expected int
+	}{
+		// 有効期限内はトークンを再利用する
+		{"有効なトークンを再利用", time.Hour, 1},
+		// 有効期限が近いトークンは再発行する
+		{"期限切れのトークンを再発行", time.Second, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAppServer{t: t, lifetime: tt.lifetime}
+			cfg := newAppConfig(t, fake)
+
+			client, err := NewClient(cfg)
+			if err != nil {
+				t.Fatalf("クライアントの生成に失敗: %v", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				if _, _, err := client.Repositories.Get(context.Background(), "org", "rules"); err != nil {
+					t.Fatalf("API呼び出しに失敗: %v", err)
+				}
+			}
+
+			if fake.issued != tt.expected {
+				t.Errorf("トークンの発行回数: 期待値 %d, 実際の値 %d", tt.expected, fake.issued)
+			}
+		})
+	}
+}
+
+func TestAppConfigErrors(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfig{App: config.AppConfig{AppID: 7, InstallationID: 42, PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}}}
+	if _, err := NewClient(cfg); err == nil || !strings.Contains(err.Error(), "秘密鍵ファイル") {
+		t.Errorf("秘密鍵ファイルがない場合のエラーが期待されました: %v", err)
+	}
+
+	cfg.Auth.App.InstallationID = 0
+	if _, err := NewClient(cfg); err == nil || !strings.Contains(err.Error(), "installation-id") {
+		t.Errorf("インストールIDがない場合のエラーが期待されました: %v", err)
+	}
+}
+
+func TestAppInstallationTokenContext(t *testing.T) {
+	fake := &fakeAppServer{t: t, lifetime: time.Hour}
+	cfg := newAppConfig(t, fake)
+
+	// トークンの発行が応答しない場合も、呼び出し元のコンテキストで打ち切る
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-released:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(released) })
+	cfg.Auth.APIURL = server.URL
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("クライアントの生成に失敗: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err = client.Repositories.Get(ctx, "org", "rules")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("タイムアウトのエラーが期待されました: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("トークンの発行がタイムアウトで打ち切られていません: %v", elapsed)
+	}
+}
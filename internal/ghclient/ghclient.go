@@ -0,0 +1,132 @@
+package ghclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"golang.org/x/oauth2"
+)
+
+// NewClient は設定に応じて認証とリトライを組み込んだGitHubクライアントを生成する
+func NewClient(cfg *config.Config) (*github.Client, error) {
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newGitHubClient(httpClient, cfg.Auth.APIURL)
+}
+
+// NewHTTPClient は設定に応じて認証とリトライを組み込んだHTTPクライアントを生成する
+// GitHub App が設定されている場合はトークンよりも優先する
+// トークンが設定されていない場合は、ここで環境変数や gh CLI などの認証情報を探す
+func NewHTTPClient(cfg *config.Config) (*http.Client, error) {
+	var logf func(format string, args ...interface{})
+	if cfg.Verbose {
+		logf = log.Printf
+	}
+
+	// 認証情報の取得元を表示（トークン自体は表示しない）
+	cfg.ResolveToken()
+	if cfg.Verbose {
+		switch {
+		case cfg.Auth.App.Enabled():
+			log.Printf("GitHub App (App ID: %d) として認証します", cfg.Auth.App.AppID)
+		case cfg.TokenSource != "":
+			log.Printf("GitHubトークンの取得元: %s", cfg.TokenSource)
+		default:
+			log.Printf("GitHubトークンが見つからないため、認証なしでアクセスします")
+		}
+	}
+
+	var transport http.RoundTripper = NewRetryTransport(http.DefaultTransport, cfg.Retry, logf)
+
+	switch {
+	case cfg.Auth.App.Enabled():
+		// GitHub App のインストールトークンを有効期限ごとに再発行して使う
+		appTransport, err := newAppTransport(cfg.Auth.App, cfg.Auth.APIURL, transport)
+		if err != nil {
+			return nil, err
+		}
+		transport = appTransport
+
+	case cfg.Auth.GitHubToken != "":
+		// GitHubトークンが設定されている場合は認証ヘッダーを付与
+		transport = &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: cfg.Auth.GitHubToken}),
+			Base:   transport,
+		}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// SetBaseURL はGitHub APIのURLを変更する（GitHub Enterprise Server やテスト用）
+func SetBaseURL(client *github.Client, apiURL string) error {
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	baseURL, err := url.Parse(apiURL)
+	if err != nil {
+		return fmt.Errorf("無効なAPIのURL: %s: %w", apiURL, err)
+	}
+	client.BaseURL = baseURL
+	return nil
+}
+
+// ParseRepoURL はGitHubリポジトリURLから所有者とリポジトリ名を抽出
+// https://、ssh:// と git@host:owner/repo（scp 形式）のURLはホストを問わず受け付ける（GitHub Enterprise Server を含む）
+func ParseRepoURL(repoURL string) (string, string, error) {
+	p := repoURL
+	switch {
+	case strings.Contains(p, "://"):
+		// https://github.com/owner/repo 形式
+		u, err := url.Parse(p)
+		if err != nil {
+			return "", "", fmt.Errorf("無効なリポジトリURL形式: %s: %w", repoURL, err)
+		}
+		p = u.Path
+	case strings.Contains(p, ":"):
+		// git@github.com:owner/repo.git 形式
+		_, p, _ = strings.Cut(p, ":")
+	}
+	// それ以外は owner/repo 形式（短縮形）
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	parts := strings.Split(p, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("無効なリポジトリURL形式: %s", repoURL)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// IsNotFound はGitHub APIのエラーが 404 Not Found かどうかを判定
+func IsNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// GetFile はリポジトリのファイルの内容を取得する。ファイルが存在しない場合は nil を返す
+// ref が空の場合はデフォルトブランチから取得する
+func GetFile(ctx context.Context, client *github.Client, owner, repo, filePath, ref string) ([]byte, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, owner, repo, filePath, &github.RepositoryContentGetOptions{Ref: ref})
+	if IsNotFound(err) || (err == nil && file == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", filePath, err)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("ファイル '%s' のコンテンツデコードに失敗: %w", filePath, err)
+	}
+	return []byte(content), nil
+}
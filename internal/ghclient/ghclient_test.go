@@ -0,0 +1,50 @@
+package ghclient
+
+import (
+	"testing"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	// 様々なURL形式を試験
+	testCases := []struct {
+		url      string
+		owner    string
+		repo     string
+		hasError bool
+	}{
+		{"https://github.com/owner/repo", "owner", "repo", false},
+		{"https://github.com/owner/repo.git", "owner", "repo", false},
+		{"https://github.com/owner/repo/", "owner", "repo", false},
+		{"git@github.com:owner/repo.git", "owner", "repo", false},
+		{"https://github.example.com/owner/repo.git", "owner", "repo", false},
+		{"git@github.example.com:owner/repo.git", "owner", "repo", false},
+		{"ssh://git@github.example.com:2222/owner/repo.git", "owner", "repo", false},
+		{"owner/repo", "owner", "repo", false},
+		{"", "", "", true},
+		{"invalid-url", "", "", true},
+		{"owner/", "", "", true},
+		{"https://github.com/owner", "", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			owner, repo, err := ParseRepoURL(tc.url)
+
+			if tc.hasError {
+				if err == nil {
+					t.Errorf("エラーが期待されましたが、成功しました: %s, %s", owner, repo)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("予期しないエラー: %v", err)
+				}
+				if owner != tc.owner {
+					t.Errorf("owner: 期待値 %s, 実際の値 %s", tc.owner, owner)
+				}
+				if repo != tc.repo {
+					t.Errorf("repo: 期待値 %s, 実際の値 %s", tc.repo, repo)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package ghclient
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+// RetryTransport はレート制限や一時的なサーバーエラーに対して
+// 待機とリトライを行う http.RoundTripper
+type RetryTransport struct {
+	// 実際のリクエストを送信するトランスポート（nilの場合は http.DefaultTransport）
+	Base http.RoundTripper
+
+	// リトライ設定
+	Config config.RetryConfig
+
+	// 待機時間などを出力するログ関数（nilの場合は出力しない）
+	Logf func(format string, args ...interface{})
+
+	// テスト用に差し替え可能な現在時刻と乱数
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+// NewRetryTransport は RetryTransport を生成する
+func NewRetryTransport(base http.RoundTripper, cfg config.RetryConfig, logf func(format string, args ...interface{})) *RetryTransport {
+	return &RetryTransport{
+		Base:   base,
+		Config: cfg,
+		Logf:   logf,
+	}
+}
+
+// RoundTrip はリクエストを送信し、必要に応じて待機してから再送する
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 {
+			var err error
+			r, err = rewindRequest(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base().RoundTrip(r)
+
+		wait, reason, retry := t.shouldRetry(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			// コネクションを再利用できるようにボディを読み捨てる
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		t.logf("%s のため %v 待機してリトライします (%d/%d): %s %s",
+			reason, wait.Round(time.Millisecond), attempt+1, t.Config.MaxRetries, req.Method, req.URL.Path)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry はレスポンスを判定し、リトライする場合は待機時間と理由を返す
+func (t *RetryTransport) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, string, bool) {
+	if attempt >= t.Config.MaxRetries {
+		return 0, "", false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// ボディを再送できないリクエストはリトライしない
+		return 0, "", false
+	}
+	if req.Context().Err() != nil {
+		return 0, "", false
+	}
+
+	var (
+		wait   time.Duration
+		reason string
+	)
+
+	switch {
+	case err != nil:
+		if !isIdempotent(req.Method) {
+			return 0, "", false
+		}
+		wait, reason = t.backoff(attempt), "通信エラー"
+
+	case isRateLimited(resp):
+		// レート制限で拒否されたリクエストは処理されていないため、メソッドに関わらず再送できる
+		wait, reason = t.rateLimitWait(resp, attempt), "レート制限"
+
+	case resp.StatusCode == http.StatusBadGateway ||
+		resp.StatusCode == http.StatusServiceUnavailable ||
+		resp.StatusCode == http.StatusGatewayTimeout:
+		if !isIdempotent(req.Method) {
+			return 0, "", false
+		}
+		wait = t.backoff(attempt)
+		if d, ok := parseRetryAfter(resp.Header); ok {
+			wait = d
+		}
+		reason = resp.Status
+
+	default:
+		return 0, "", false
+	}
+
+	if t.Config.MaxWait > 0 && wait > t.Config.MaxWait {
+		t.logf("%s の待機時間 %v が上限 %v を超えるためリトライしません", reason, wait.Round(time.Second), t.Config.MaxWait)
+		return 0, "", false
+	}
+
+	return wait, reason, true
+}
+
+// secondaryRateLimitWait は待機時間を示すヘッダーのないレート制限（セカンダリレート制限など）で待つ最短の時間
+// GitHub は1分以上待ってから再送するよう案内しており、早く再送すると制限の期間が延びる
+const secondaryRateLimitWait = time.Minute
+
+// rateLimitWait は Retry-After または X-RateLimit-Reset から待機時間を算出する
+// どちらのヘッダーもない場合は secondaryRateLimitWait 以上（MaxWait が上限）待つ
+func (t *RetryTransport) rateLimitWait(resp *http.Response, attempt int) time.Duration {
+	if d, ok := parseRetryAfter(resp.Header); ok {
+		return d
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// 時計のずれを考慮して1秒余裕を持たせる
+			d := time.Unix(reset, 0).Sub(t.currentTime()) + time.Second
+			if d < 0 {
+				d = 0
+			}
+			return d
+		}
+	}
+
+	d := t.backoff(attempt)
+	if d < secondaryRateLimitWait {
+		d = secondaryRateLimitWait
+	}
+	if t.Config.MaxWait > 0 && d > t.Config.MaxWait {
+		d = t.Config.MaxWait
+	}
+	return d
+}
+
+// backoff はジッター付きの指数バックオフ待機時間を返す
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.Config.BaseDelay
+	if d <= 0 {
+		d = time.Second
+	}
+	for i := 0; i < attempt; i++ {
+		d *= 2
+		if t.Config.MaxWait > 0 && d >= t.Config.MaxWait {
+			d = t.Config.MaxWait
+			break
+		}
+	}
+
+	if t.jitter != nil {
+		return t.jitter(d)
+	}
+	// 待機時間の半分から全体までの範囲でランダムにずらす
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func (t *RetryTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RetryTransport) currentTime() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *RetryTransport) logf(format string, args ...interface{}) {
+	if t.Logf != nil {
+		t.Logf(format, args...)
+	}
+}
+
+// isRateLimited はプライマリまたはセカンダリレート制限によるエラーかどうかを判定
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "" {
+			return true
+		}
+		// セカンダリレート制限はヘッダーなしで返ることがあるため本文を確認する
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return false
+		}
+		return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+	}
+	return false
+}
+
+// parseRetryAfter は Retry-After ヘッダー（秒数）を解析する
+func parseRetryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// isIdempotent は再送しても副作用が変わらないHTTPメソッドかどうかを判定
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewindRequest は再送用にボディを巻き戻したリクエストを生成する
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
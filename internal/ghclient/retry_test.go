@@ -0,0 +1,184 @@
+package ghclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+// newTestTransport は待機時間をほぼゼロにしたテスト用トランスポートを生成
+func newTestTransport(maxRetries int) *RetryTransport {
+	t := NewRetryTransport(http.DefaultTransport, config.RetryConfig{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Millisecond,
+		MaxWait:    time.Second,
+	}, nil)
+	t.jitter = func(d time.Duration) time.Duration { return d }
+	return t
+}
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		failures      int
+		failStatus    int
+		failHeader    map[string]string
+		failBody      string
+		expectStatus  int
+		expectAttempt int32
+	}{
+		{"503はリトライする", http.MethodGet, 2, http.StatusServiceUnavailable, nil, "", http.StatusOK, 3},
+		{"502はリトライする", http.MethodPut, 1, http.StatusBadGateway, nil, "", http.StatusOK, 2},
+		{"POSTの503はリトライしない", http.MethodPost, 1, http.StatusServiceUnavailable, nil, "", http.StatusServiceUnavailable, 1},
+		{"429はPOSTでもリトライする", http.MethodPost, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}, "", http.StatusOK, 2},
+		{"プライマリレート制限", http.MethodGet, 1, http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "0"}, "", http.StatusOK, 2},
+		{"セカンダリレート制限", http.MethodPost, 1, http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`, http.StatusOK, 2},
+		{"権限不足の403はリトライしない", http.MethodGet, 1, http.StatusForbidden, nil, `{"message":"Resource not accessible"}`, http.StatusForbidden, 1},
+		{"最大リトライ回数で諦める", http.MethodGet, 10, http.StatusServiceUnavailable, nil, "", http.StatusServiceUnavailable, 3},
+		{"待機時間が上限を超える場合は諦める", http.MethodGet, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}, "", http.StatusTooManyRequests, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if int(n) <= tc.failures {
+					for k, v := range tc.failHeader {
+						w.Header().Set(k, v)
+					}
+					w.WriteHeader(tc.failStatus)
+					_, _ = w.Write([]byte(tc.failBody))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newTestTransport(2)}
+			req, err := http.NewRequest(tc.method, server.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatalf("リクエストの作成に失敗: %v", err)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("リクエストに失敗: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expectStatus {
+				t.Errorf("ステータス: 期待値 %d, 実際の値 %d", tc.expectStatus, resp.StatusCode)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tc.expectAttempt {
+				t.Errorf("試行回数: 期待値 %d, 実際の値 %d", tc.expectAttempt, got)
+			}
+		})
+	}
+}
+
+func TestRetryTransportResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newTestTransport(2)}
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("リクエストの作成に失敗: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("リクエストに失敗: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Errorf("再送時のボディが一致しません: %q", bodies)
+	}
+}
+
+func TestRetryTransportContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &http.Client{Transport: newTestTransport(3)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("リクエストの作成に失敗: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Do(req)
+	if err == nil {
+		t.Fatalf("キャンセルによるエラーが期待されましたが、成功しました")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("キャンセル後も待機が続きました: %v", elapsed)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tr := newTestTransport(3)
+	tr.now = func() time.Time { return now }
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", "1030")
+
+	if got := tr.rateLimitWait(resp, 0); got != 31*time.Second {
+		t.Errorf("待機時間: 期待値 %v, 実際の値 %v", 31*time.Second, got)
+	}
+
+	resp.Header.Set("Retry-After", "5")
+	if got := tr.rateLimitWait(resp, 0); got != 5*time.Second {
+		t.Errorf("Retry-After 優先時の待機時間: 期待値 %v, 実際の値 %v", 5*time.Second, got)
+	}
+
+	// ヘッダーのないセカンダリレート制限は1分以上待つ（MaxWait が上限）
+	resp = &http.Response{Header: http.Header{}}
+	tr.Config.MaxWait = 2 * time.Minute
+	if got := tr.rateLimitWait(resp, 0); got != time.Minute {
+		t.Errorf("ヘッダーのない場合の待機時間: 期待値 %v, 実際の値 %v", time.Minute, got)
+	}
+	tr.Config.MaxWait = 10 * time.Second
+	if got := tr.rateLimitWait(resp, 0); got != 10*time.Second {
+		t.Errorf("MaxWait を上限とする待機時間: 期待値 %v, 実際の値 %v", 10*time.Second, got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := newTestTransport(5)
+	tr.Config.BaseDelay = 100 * time.Millisecond
+	tr.Config.MaxWait = 300 * time.Millisecond
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for attempt, want := range expected {
+		if got := tr.backoff(attempt); got != want {
+			t.Errorf("attempt %d: 期待値 %v, 実際の値 %v", attempt, want, got)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package gitutil
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// BlobSHA は内容から git の blob オブジェクトの SHA-1 を計算する
+// `git hash-object` と同じ値になるため、GitHub API が返すファイルの SHA と比較できる
+func BlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package gitutil
+
+import "testing"
+
+func TestBlobSHA(t *testing.T) {
+	// 期待値は `git hash-object` で計算した値
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"", "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{"hello\n", "ce013625030ba8dba906f756967f9e9ca394464a"},
+	}
+
+	for _, tt := range tests {
+		if got := BlobSHA([]byte(tt.content)); got != tt.expected {
+			t.Errorf("BlobSHA(%q) = %s, 期待値 %s", tt.content, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package gitutil
+
+import "path/filepath"
+
+// HooksDir は dir のリポジトリで git が実行するフックのディレクトリを返す
+// core.hooksPath やワークツリーも git rev-parse --git-path に任せて解決する
+func HooksDir(dir string) (string, error) {
+	p, err := git(dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Abs(p)
+}
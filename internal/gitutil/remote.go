@@ -0,0 +1,207 @@
+package gitutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Remote は git remote のURLから取り出したリポジトリの情報
+type Remote struct {
+	// ホスト名（github.com、GitHub Enterprise Server や GitLab のホストなど）
+	Host string
+
+	// リポジトリの所有者（GitLab のサブグループの場合は group/subgroup）
+	Owner string
+
+	// リポジトリ名（.git は含まない）
+	Repo string
+}
+
+// DetectRemote は dir を含むGitリポジトリのリモート（origin、なければ最初のリモート）を返す
+// git コマンドで取得するため、ワークツリーやサブモジュール、url.<base>.insteadOf による書き換えにも対応する
+// git コマンドが使えない場合は .git の設定ファイルを直接読む
+func DetectRemote(dir string) (*Remote, error) {
+	rawURL, err := remoteURLFromGit(dir)
+	if err != nil {
+		if _, lookErr := exec.LookPath("git"); lookErr == nil {
+			return nil, err
+		}
+		if rawURL, err = remoteURLFromConfig(dir); err != nil {
+			return nil, err
+		}
+	}
+	return ParseRemoteURL(rawURL)
+}
+
+// ParseRemoteURL は git remote のURL（https://、ssh://、git@host:owner/repo の形式）を解析する
+func ParseRemoteURL(rawURL string) (*Remote, error) {
+	var host, p string
+	switch {
+	case strings.Contains(rawURL, "://"):
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("リモートのURLを解析できません: %s: %w", rawURL, err)
+		}
+		if u.Scheme == "file" {
+			return nil, fmt.Errorf("ローカルのリモートからはリポジトリを特定できません: %s", rawURL)
+		}
+		host, p = u.Hostname(), u.Path
+	default:
+		// scp 形式（[user@]host:owner/repo）
+		before, after, ok := strings.Cut(rawURL, ":")
+		if !ok || strings.Contains(before, "/") {
+			return nil, fmt.Errorf("ローカルのリモートからはリポジトリを特定できません: %s", rawURL)
+		}
+		if _, h, ok := strings.Cut(before, "@"); ok {
+			before = h
+		}
+		host, p = before, after
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	i := strings.LastIndex(p, "/")
+	if host == "" || i <= 0 || i == len(p)-1 {
+		return nil, fmt.Errorf("リモートのURLから所有者とリポジトリ名を特定できません: %s", rawURL)
+	}
+	return &Remote{Host: host, Owner: p[:i], Repo: p[i+1:]}, nil
+}
+
+// remoteURLFromGit は git コマンドでリモートのURLを取得する
+func remoteURLFromGit(dir string) (string, error) {
+	names, err := git(dir, "remote")
+	if err != nil {
+		return "", err
+	}
+	name, err := chooseRemote(strings.Fields(names))
+	if err != nil {
+		return "", err
+	}
+	return git(dir, "remote", "get-url", name)
+}
+
+// git は dir で git コマンドを実行し、標準出力を返す
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s に失敗: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s に失敗: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// chooseRemote は origin を優先し、なければ最初のリモートを選ぶ
+func chooseRemote(names []string) (string, error) {
+	if len(names) == 0 {
+		return "", fmt.Errorf("リモートが設定されていません")
+	}
+	for _, name := range names {
+		if name == "origin" {
+			return name, nil
+		}
+	}
+	return names[0], nil
+}
+
+// remoteURLFromConfig は .git の設定ファイルからリモートのURLを読み込む
+func remoteURLFromConfig(dir string) (string, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// ワークツリーの設定はメインのリポジトリ（commondir）にある
+	configDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		configDir = common
+	}
+
+	f, err := os.Open(filepath.Join(configDir, "config"))
+	if err != nil {
+		return "", fmt.Errorf("Gitの設定ファイルを開けません: %w", err)
+	}
+	defer f.Close()
+
+	urls := map[string]string{}
+	var names []string
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = ""
+			if name, ok := strings.CutPrefix(strings.Trim(line, "[]"), "remote "); ok {
+				section = strings.Trim(strings.TrimSpace(name), `"`)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if section == "" || !ok || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		if _, seen := urls[section]; !seen {
+			names = append(names, section)
+		}
+		urls[section] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("Gitの設定ファイルの読み込みに失敗: %w", err)
+	}
+
+	name, err := chooseRemote(names)
+	if err != nil {
+		return "", err
+	}
+	return urls[name], nil
+}
+
+// findGitDir は dir から親ディレクトリをたどって .git を探す
+// .git がファイルの場合（ワークツリーやサブモジュール）は gitdir: の指すディレクトリを返す
+func findGitDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, ".git")
+		info, err := os.Stat(candidate)
+		if err == nil {
+			if info.IsDir() {
+				return candidate, nil
+			}
+			data, err := os.ReadFile(candidate)
+			if err != nil {
+				return "", fmt.Errorf("%s の読み込みに失敗: %w", candidate, err)
+			}
+			gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+			if !ok {
+				return "", fmt.Errorf("%s の形式が不正です", candidate)
+			}
+			gitDir = strings.TrimSpace(gitDir)
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return gitDir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("Gitリポジトリが見つかりません")
+		}
+		dir = parent
+	}
+}
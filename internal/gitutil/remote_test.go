@@ -0,0 +1,110 @@
+package gitutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Remote
+		wantErr bool
+	}{
+		{url: "https://github.com/org-a/api.git", want: Remote{"github.com", "org-a", "api"}},
+		{url: "https://github.com/org-a/api/", want: Remote{"github.com", "org-a", "api"}},
+		{url: "git@github.com:org-b/api.git", want: Remote{"github.com", "org-b", "api"}},
+		{url: "ssh://git@github.example.com:2222/team/service.git", want: Remote{"github.example.com", "team", "service"}},
+		{url: "https://user@gitlab.example.com/group/sub/app", want: Remote{"gitlab.example.com", "group/sub", "app"}},
+		{url: "gitea.local:owner/repo", want: Remote{"gitea.local", "owner", "repo"}},
+		{url: "/srv/git/repo.git", wantErr: true},
+		{url: "file:///srv/git/owner/repo.git", wantErr: true},
+		{url: "https://github.com/repo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRemoteURL(tt.url)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRemoteURL(%q): エラーが期待されましたが成功しました: %+v", tt.url, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemoteURL(%q): 予期しないエラー: %v", tt.url, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseRemoteURL(%q) = %+v, 期待値 %+v", tt.url, *got, tt.want)
+		}
+	}
+}
+
+func TestRemoteURLFromConfigWorktree(t *testing.T) {
+	// メインのリポジトリ（.git ディレクトリ）と、.git ファイルで参照するワークツリー
+	mainDir := t.TempDir()
+	gitDir := filepath.Join(mainDir, ".git")
+	worktreeGitDir := filepath.Join(gitDir, "worktrees", "feature")
+	if err := os.MkdirAll(worktreeGitDir, 0755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+	config := "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = git@github.com:upstream/api.git\n[remote \"origin\"]\n\turl = https://github.com/org-a/api.git\n"
+	if err := os.WriteFile(filepath.Join(gitDir, "config"), []byte(config), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatalf("commondir の作成に失敗: %v", err)
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644); err != nil {
+		t.Fatalf(".git ファイルの作成に失敗: %v", err)
+	}
+	sub := filepath.Join(worktree, "services", "billing")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("ディレクトリの作成に失敗: %v", err)
+	}
+
+	// サブディレクトリからでもリポジトリのルートの .git を見つけ、origin を優先する
+	for _, dir := range []string{mainDir, sub} {
+		got, err := remoteURLFromConfig(dir)
+		if err != nil {
+			t.Fatalf("%s: リモートの取得に失敗: %v", dir, err)
+		}
+		if got != "https://github.com/org-a/api.git" {
+			t.Errorf("%s: 期待値 https://github.com/org-a/api.git, 実際の値 %s", dir, got)
+		}
+	}
+}
+
+func TestDetectRemoteWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git コマンドがありません")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v に失敗: %v\n%s", args, err, out)
+		}
+	}
+	run(repo, "init", "-q")
+	run(repo, "remote", "add", "origin", "git@github.example.com:org-b/api.git")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "initial")
+	worktree := filepath.Join(t.TempDir(), "wt")
+	run(repo, "worktree", "add", "-q", worktree)
+
+	got, err := DetectRemote(worktree)
+	if err != nil {
+		t.Fatalf("リモートの検出に失敗: %v", err)
+	}
+	if want := (Remote{"github.example.com", "org-b", "api"}); *got != want {
+		t.Errorf("期待値 %+v, 実際の値 %+v", want, *got)
+	}
+}
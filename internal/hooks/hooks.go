@@ -0,0 +1,164 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// marker は ruleforge が生成したフックかどうかを判定するためにスクリプトに含める行
+const marker = "# ruleforge hook"
+
+// chainSuffix はインストール前からあったフックを退避するときに付ける接尾辞
+// ruleforge のフックは退避したフックを先に実行する
+const chainSuffix = ".pre-ruleforge"
+
+// Names はインストールするフック
+var Names = []string{"post-checkout", "post-merge", "pre-push"}
+
+// Options は hooks install のオプション
+type Options struct {
+	// post-checkout と post-merge でルールをダウンロードする（false の場合は差分の警告だけを表示する）
+	Download bool
+
+	// 結果の出力先
+	Out io.Writer
+}
+
+// Install は dir のリポジトリにフックをインストールする
+// フックのディレクトリは core.hooksPath を含めて git に問い合わせる
+// 既存のフックは退避して ruleforge のフックから呼び出し、ruleforge のフックは上書きする
+func Install(dir string, opts Options) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(install(dir, opts, res))
+}
+
+func install(dir string, opts Options, res *output.Result) error {
+	hooksDir, err := gitutil.HooksDir(dir)
+	if err != nil {
+		return fmt.Errorf("フックのディレクトリの取得に失敗: %w", err)
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("フックのディレクトリ '%s' の作成に失敗: %w", hooksDir, err)
+	}
+
+	for _, name := range Names {
+		hookPath := filepath.Join(hooksDir, name)
+		installed, err := isInstalled(hookPath)
+		if err != nil {
+			return err
+		}
+
+		if !installed && exists(hookPath) {
+			chained := hookPath + chainSuffix
+			if exists(chained) {
+				return fmt.Errorf("フック '%s' を退避できません。'%s' が既に存在します", hookPath, chained)
+			}
+			if err := os.Rename(hookPath, chained); err != nil {
+				return fmt.Errorf("フック '%s' の退避に失敗: %w", hookPath, err)
+			}
+			fmt.Fprintf(opts.Out, "既存のフック %s を %s に退避しました（ruleforge のフックから先に実行します）\n", name, filepath.Base(chained))
+		}
+
+		if err := os.WriteFile(hookPath, []byte(script(name, opts.Download)), 0755); err != nil {
+			return fmt.Errorf("フック '%s' の書き込みに失敗: %w", hookPath, err)
+		}
+		// 既存のファイルを上書きした場合は WriteFile でパーミッションが変わらないため明示的に設定する
+		if err := os.Chmod(hookPath, 0755); err != nil {
+			return fmt.Errorf("フック '%s' のパーミッションの設定に失敗: %w", hookPath, err)
+		}
+		fmt.Fprintf(opts.Out, "フック %s をインストールしました: %s\n", name, hookPath)
+		res.Files = append(res.Files, output.File{Path: hookPath, Status: output.StatusInstalled})
+	}
+	return nil
+}
+
+// Uninstall は ruleforge のフックを削除し、退避したフックを元に戻す
+// ruleforge が生成していないフックは変更しない
+func Uninstall(dir string, out io.Writer) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(uninstall(dir, out, res))
+}
+
+func uninstall(dir string, out io.Writer, res *output.Result) error {
+	hooksDir, err := gitutil.HooksDir(dir)
+	if err != nil {
+		return fmt.Errorf("フックのディレクトリの取得に失敗: %w", err)
+	}
+
+	for _, name := range Names {
+		hookPath := filepath.Join(hooksDir, name)
+		installed, err := isInstalled(hookPath)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			continue
+		}
+
+		if err := os.Remove(hookPath); err != nil {
+			return fmt.Errorf("フック '%s' の削除に失敗: %w", hookPath, err)
+		}
+		if chained := hookPath + chainSuffix; exists(chained) {
+			if err := os.Rename(chained, hookPath); err != nil {
+				return fmt.Errorf("フック '%s' の復元に失敗: %w", hookPath, err)
+			}
+			fmt.Fprintf(out, "フック %s を削除し、退避していたフックを元に戻しました\n", name)
+			res.Files = append(res.Files, output.File{Path: hookPath, Status: output.StatusRestored})
+			continue
+		}
+		fmt.Fprintf(out, "フック %s を削除しました\n", name)
+		res.Files = append(res.Files, output.File{Path: hookPath, Status: output.StatusRemoved})
+	}
+	return nil
+}
+
+// isInstalled は path が ruleforge の生成したフックかどうかを返す
+func isInstalled(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("フック '%s' の読み込みに失敗: %w", path, err)
+	}
+	return strings.Contains(string(data), "\n"+marker+"\n"), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// script はフックのシェルスクリプトを生成する
+// ruleforge が PATH にない環境（GUI クライアントなど）ではフックを失敗させずにスキップする
+func script(name string, download bool) string {
+	args := name
+	if download && name != "pre-push" {
+		args += " --download"
+	}
+
+	return fmt.Sprintf(`#!/bin/sh
+%s
+# ruleforge hooks install で生成（ruleforge hooks uninstall で削除）
+
+# インストール前からあったフックを先に実行する
+chained="$(dirname "$0")/%s%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+
+if ! command -v ruleforge >/dev/null 2>&1; then
+	echo "ruleforge: コマンドが見つからないため、%s フックをスキップします" >&2
+	exit 0
+fi
+exec ruleforge hooks run %s -- "$@"
+`, marker, name, chainSuffix, name, args)
+}
@@ -0,0 +1,171 @@
+package hooks
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// newRepo は core.hooksPath を設定した git リポジトリを作成し、リポジトリとフックのディレクトリを返す
+func newRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git コマンドがありません")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"config", "core.hooksPath", ".githooks"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v に失敗: %v\n%s", args, err, out)
+		}
+	}
+	return repo, filepath.Join(repo, ".githooks")
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	repo, hooksDir := newRepo(t)
+
+	// core.hooksPath に既存のフックがある
+	original := "#!/bin/sh\necho existing\n"
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push"), []byte(original), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	res, err := Install(repo, Options{Download: true, Out: &out})
+	if err != nil {
+		t.Fatalf("インストールに失敗: %v", err)
+	}
+	if len(res.Files) != len(Names) || res.Files[0].Status != output.StatusInstalled {
+		t.Errorf("インストールしたフックが結果に記録されていません: %+v", res.Files)
+	}
+	// 2回目のインストールでは退避したフックを上書きしない
+	if _, err := Install(repo, Options{Download: true, Out: &out}); err != nil {
+		t.Fatalf("再インストールに失敗: %v", err)
+	}
+
+	for _, name := range Names {
+		installed, err := isInstalled(filepath.Join(hooksDir, name))
+		if err != nil || !installed {
+			t.Errorf("%s がインストールされていません: %v", name, err)
+		}
+	}
+	if chained, _ := os.ReadFile(filepath.Join(hooksDir, "pre-push"+chainSuffix)); string(chained) != original {
+		t.Errorf("既存のフックが退避されていません: %q", chained)
+	}
+	if hook, _ := os.ReadFile(filepath.Join(hooksDir, "post-merge")); !strings.Contains(string(hook), "hooks run post-merge --download") {
+		t.Errorf("post-merge でダウンロードしません:\n%s", hook)
+	}
+
+	res, err = Uninstall(repo, &out)
+	if err != nil {
+		t.Fatalf("アンインストールに失敗: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, f := range res.Files {
+		statuses[filepath.Base(f.Path)] = f.Status
+	}
+	if statuses["pre-push"] != output.StatusRestored || statuses["post-merge"] != output.StatusRemoved {
+		t.Errorf("アンインストールの結果が期待と異なります: %v", statuses)
+	}
+	if restored, _ := os.ReadFile(filepath.Join(hooksDir, "pre-push")); string(restored) != original {
+		t.Errorf("既存のフックが元に戻っていません: %q", restored)
+	}
+	if _, err := os.Stat(filepath.Join(hooksDir, "post-merge")); !os.IsNotExist(err) {
+		t.Errorf("post-merge が削除されていません: %v", err)
+	}
+}
+
+func TestInstalledHookRunsChained(t *testing.T) {
+	repo, hooksDir := newRepo(t)
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 既存のフックは引数と標準入力を受け取り、その終了コードでプッシュを止められる
+	record := filepath.Join(t.TempDir(), "record")
+	existing := "#!/bin/sh\necho \"$@\" > " + record + "\ncat >> " + record + "\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push"), []byte(existing), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(repo, Options{Out: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("インストールに失敗: %v", err)
+	}
+
+	cmd := exec.Command(filepath.Join(hooksDir, "pre-push"), "origin", "git@github.com:org/app.git")
+	cmd.Stdin = strings.NewReader("refs/heads/main abc refs/heads/main def\n")
+	cmd.Env = append(os.Environ(), "PATH=/usr/bin:/bin")
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("既存のフックの終了コードが返されていません: %v", err)
+	}
+
+	want := "origin git@github.com:org/app.git\nrefs/heads/main abc refs/heads/main def\n"
+	if got, _ := os.ReadFile(record); string(got) != want {
+		t.Errorf("既存のフックの引数と標準入力: 期待値 %q, 実際の値 %q", want, got)
+	}
+}
+
+func TestCheckPush(t *testing.T) {
+	root := t.TempDir()
+	billing := filepath.Join(root, "billing")
+	if err := os.MkdirAll(billing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projects := []*config.Project{
+		{Path: ".", Config: &config.Config{LocalDir: root, Files: []string{"AGENTS.md"}}},
+		{Path: "billing", Config: &config.Config{LocalDir: billing, Files: []string{"AGENTS.md", "CLAUDE.md"}}},
+	}
+	for _, dir := range []string{root, billing} {
+		if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("rules"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ロックファイルがなければ確認しない
+	if err := CheckPush(projects, &bytes.Buffer{}); err != nil {
+		t.Fatalf("ロックファイルがない場合にプッシュが止められました: %v", err)
+	}
+
+	for _, p := range projects {
+		err := lockfile.Update(lockfile.Path(p.Config), "org/rules", func(lock *lockfile.Lockfile) {
+			lock.Set("AGENTS.md", "AGENTS.md", []byte("rules"), lockfile.SourceDownload)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CheckPush(projects, &bytes.Buffer{}); err != nil {
+		t.Fatalf("記録と一致しているのにプッシュが止められました: %v", err)
+	}
+
+	// 記録と異なるファイルと、記録のない新しいファイルがあればプッシュを止める
+	if err := os.WriteFile(filepath.Join(root, "AGENTS.md"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(billing, "CLAUDE.md"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := CheckPush(projects, &out)
+	if err == nil || !strings.Contains(err.Error(), "2 件") {
+		t.Errorf("プッシュを止めるエラーが期待されました: %v", err)
+	}
+	for _, want := range []string{"  AGENTS.md\n", "  billing/CLAUDE.md\n", "ruleforge upload"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/lockfile"
+	"github.com/hiroyannnn/ruleforge/internal/project"
+	"github.com/hiroyannnn/ruleforge/internal/status"
+)
+
+// refreshTimeout は post-checkout と post-merge でベースリポジトリに問い合わせる時間の上限
+// チェックアウトやマージを長く待たせないよう、上限を超えた場合は確認を諦める
+const refreshTimeout = 20 * time.Second
+
+// RunOptions は hooks run のオプション
+type RunOptions struct {
+	// post-checkout と post-merge でルールをダウンロードする
+	Download bool
+
+	// 結果の出力先
+	Out io.Writer
+}
+
+// Run は git から呼び出されたフックの処理を実行する
+// args は git がフックに渡した引数
+func Run(ctx context.Context, hook string, args []string, projects []*config.Project, opts RunOptions) error {
+	switch hook {
+	case "post-checkout":
+		// ファイル単位のチェックアウト（3番目の引数が 0）ではルールは変わらない
+		if len(args) >= 3 && args[2] == "0" {
+			return nil
+		}
+		refresh(ctx, projects, opts)
+		return nil
+	case "post-merge":
+		refresh(ctx, projects, opts)
+		return nil
+	case "pre-push":
+		return CheckPush(projects, opts.Out)
+	default:
+		return fmt.Errorf("不明なフック: %s（%s のいずれかを指定してください）", hook, strings.Join(Names, ", "))
+	}
+}
+
+// refresh はルールをダウンロードするか、ベースリポジトリとの差分を警告する
+// post-checkout と post-merge の終了コードは git の操作に影響しないため、失敗は警告として表示する
+func refresh(ctx context.Context, projects []*config.Project, opts RunOptions) {
+	if len(projects) == 0 || projects[0].Config.BaseRepo == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	if opts.Download {
+		err := project.Run(ctx, projects, func(ctx context.Context, p *config.Project) error {
+			_, err := download.Execute(ctx, p.Config)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(opts.Out, "ruleforge: ルールのダウンロードに失敗しました: %v\n", err)
+		}
+		return
+	}
+
+	var outdated []string
+	for _, p := range projects {
+		statuses, err := status.Execute(ctx, p.Config, io.Discard)
+		if err != nil {
+			fmt.Fprintf(opts.Out, "ruleforge: ベースリポジトリとの比較に失敗しました: %v\n", err)
+			return
+		}
+		for _, s := range statuses {
+			if s.State != drift.StateUpToDate {
+				outdated = append(outdated, path.Join(p.Path, s.Path))
+			}
+		}
+	}
+	if len(outdated) > 0 {
+		fmt.Fprintf(opts.Out, "ruleforge: 警告: 次のルールファイルがベースリポジトリと異なります: %s\n", strings.Join(outdated, ", "))
+		fmt.Fprintln(opts.Out, "ruleforge: ruleforge download で更新できます")
+	}
+}
+
+// CheckPush はローカルのルールファイルがロックファイルの記録と一致しているかを確認する
+// ダウンロードまたはアップロードしていない変更がある場合はエラーを返す
+// ロックファイルのないプロジェクトは確認しない
+func CheckPush(projects []*config.Project, out io.Writer) error {
+	var diverged []string
+	for _, p := range projects {
+		lockPath := lockfile.Path(p.Config)
+		if _, err := os.Stat(lockPath); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		lock, err := lockfile.Load(lockPath)
+		if err != nil {
+			return err
+		}
+		files, err := lock.Diverged(p.Config)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			diverged = append(diverged, path.Join(p.Path, f))
+		}
+	}
+	if len(diverged) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out, "ruleforge: 次のルールファイルがアップロードされていません:")
+	for _, f := range diverged {
+		fmt.Fprintf(out, "  %s\n", f)
+	}
+	fmt.Fprintln(out, "ruleforge: ruleforge upload でアップロードするか、ruleforge download で元に戻してからプッシュしてください（確認せずにプッシュする場合は git push --no-verify）")
+	return fmt.Errorf("%d 件のルールファイルがロックファイルの記録と異なるため、プッシュを中止しました", len(diverged))
+}
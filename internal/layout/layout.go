@@ -0,0 +1,167 @@
+package layout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/prompt"
+	"github.com/hiroyannnn/ruleforge/internal/publish"
+)
+
+// Options は layout migrate コマンドの実行オプション
+type Options struct {
+	// 移行元の flat 形式のファイルを削除せずに残す
+	Keep bool
+
+	// 確認せずにプルリクエストを作成する
+	Yes bool
+
+	// 確認の入力と移行内容の出力先（nilの場合は標準入出力）
+	In  io.Reader
+	Out io.Writer
+}
+
+// move はベースリポジトリの1ファイル分の移動
+type move struct {
+	from    string
+	to      string
+	content []byte
+}
+
+// Execute はカレントリポジトリのルールを flat 形式の <repo-name>/ から
+// owner 形式の <repo-owner>/<repo-name>/ に移動するプルリクエストを作成する
+// 移動するのは対象ファイル（target-files）だけのため、同名の別リポジトリのファイルには影響しない
+func Execute(ctx context.Context, cfg *config.Config, opts Options) (*output.Result, error) {
+	res := output.NewResult("")
+	if !cfg.HasCredentials() {
+		return res, res.Fail(fmt.Errorf("GitHub APIトークンが設定されていません。環境変数 GITHUB_TOKEN を設定するか、設定ファイルで指定してください"))
+	}
+
+	owner, repo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
+	}
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
+	}
+
+	return res, res.Fail(run(ctx, client, owner, repo, cfg, opts, res))
+}
+
+func run(ctx context.Context, client *github.Client, owner, repo string, cfg *config.Config, opts Options, res *output.Result) error {
+	in, out := opts.In, opts.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if cfg.RepoName == "" || cfg.RepoOwner == "" {
+		return fmt.Errorf("リポジトリの所有者と名前を検出できませんでした。設定ファイルの repo-owner と repo-name で指定してください")
+	}
+	oldDir := cfg.RepoName
+	newDir := path.Join(cfg.RepoOwner, cfg.RepoName)
+
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("リポジトリ情報の取得に失敗: %w", err)
+	}
+
+	moves, err := plan(ctx, client, owner, repo, repository.GetDefaultBranch(), oldDir, newDir, cfg.Files, res)
+	if err != nil {
+		return err
+	}
+	if len(moves) == 0 {
+		fmt.Fprintf(out, "%s/ に移行するファイルはありません\n", oldDir)
+		return nil
+	}
+
+	fmt.Fprintf(out, "ルールを %s/ から %s/ に移動します:\n", oldDir, newDir)
+	var files []publish.File
+	var deletions []string
+	for _, m := range moves {
+		fmt.Fprintf(out, "  %s -> %s\n", m.from, m.to)
+		files = append(files, publish.File{Path: m.to, Content: m.content, Source: m.from})
+		if !opts.Keep {
+			deletions = append(deletions, m.from)
+		}
+	}
+	if opts.Keep {
+		fmt.Fprintf(out, "%s/ のファイルは削除せずに残します\n", oldDir)
+	}
+
+	if !opts.Yes {
+		ok, err := prompt.New(in, out).Confirm("この内容でプルリクエストを作成しますか?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "中止しました")
+			return nil
+		}
+	}
+
+	message := fmt.Sprintf("Move %s rules to %s/", oldDir, newDir)
+	result, err := publish.Run(ctx, client, &publish.Request{
+		Owner:       owner,
+		Repo:        repo,
+		Branch:      fmt.Sprintf("migrate-layout-%s-%s", cfg.RepoOwner, cfg.RepoName),
+		Files:       files,
+		Deletions:   deletions,
+		Message:     message,
+		Title:       fmt.Sprintf("[%s] %s", newDir, message),
+		Body:        fmt.Sprintf("%s のルールを owner 形式の配置（%s/）に移動します。\n", path.Join(cfg.RepoOwner, cfg.RepoName), newDir),
+		PullRequest: cfg.PullRequest,
+		Verbose:     cfg.Verbose,
+	})
+	if err != nil {
+		return err
+	}
+	// 移動元はローカルのファイルではないため、ベースリポジトリ上のパスだけを記録する
+	for i := range files {
+		files[i].Source = ""
+	}
+	result.Record(res, files)
+
+	fmt.Fprintf(out, "プルリクエストのマージ後、設定ファイルに layout: %s を設定してください\n", config.LayoutOwner)
+	return nil
+}
+
+// plan は移動するファイルを求める
+// 移動先に既にファイルがある場合は上書きせずにスキップする
+func plan(ctx context.Context, client *github.Client, owner, repo, ref, oldDir, newDir string, targetFiles []string, res *output.Result) ([]move, error) {
+	var moves []move
+	for _, filePath := range targetFiles {
+		from := path.Join(oldDir, filePath)
+		to := path.Join(newDir, filePath)
+
+		content, err := ghclient.GetFile(ctx, client, owner, repo, from, ref)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+
+		existing, err := ghclient.GetFile(ctx, client, owner, repo, to, ref)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			res.Warn("'%s' は既に存在するため、'%s' は移動しません", to, from)
+			continue
+		}
+
+		moves = append(moves, move{from: from, to: to, content: content})
+	}
+	return moves, nil
+}
@@ -0,0 +1,200 @@
+package layout
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// fakeRepo はベースリポジトリのファイルの取得・書き込み・削除とPR作成だけを扱う簡易的なGitHub APIのモック
+type fakeRepo struct {
+	mu      sync.Mutex
+	files   map[string]string // パス -> 内容
+	written map[string]string // 作業用ブランチに書き込まれた内容
+	deleted []string
+	pulls   int
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	p := strings.TrimPrefix(r.URL.Path, "/repos/org/rules")
+	filePath := strings.TrimPrefix(p, "/contents/")
+
+	switch {
+	case p == "" && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+
+	case strings.HasPrefix(p, "/git/ref/") && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`))
+
+	case p == "/git/refs" && r.Method == "POST":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/branch"}`))
+
+	case strings.HasPrefix(p, "/contents/") && r.Method == "GET":
+		content, ok := f.files[filePath]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"sha":      "sha-" + filePath,
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case strings.HasPrefix(p, "/contents/") && r.Method == "PUT":
+		var body struct {
+			Content []byte `json:"content"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		f.written[filePath] = string(body.Content)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"content": {}}`))
+
+	case strings.HasPrefix(p, "/contents/") && r.Method == "DELETE":
+		f.deleted = append(f.deleted, filePath)
+		_, _ = w.Write([]byte(`{"commit": {}}`))
+
+	case p == "/pulls" && r.Method == "POST":
+		f.pulls++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 1}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("URLの解析に失敗: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		files: map[string]string{
+			"api/rules.md":        "# API rules\n",
+			"api/CLAUDE.md":       "# Claude\n",
+			"org-a/api/CLAUDE.md": "# Already moved\n",
+		},
+		written: map[string]string{},
+	}
+}
+
+func TestRunMovesTargetFiles(t *testing.T) {
+	fake := newFakeRepo()
+	client := newTestClient(t, fake)
+
+	var out bytes.Buffer
+	cfg := &config.Config{Files: []string{"rules.md", "CLAUDE.md", "AGENTS.md"}, RepoOwner: "org-a", RepoName: "api"}
+	opts := Options{In: strings.NewReader("y\n"), Out: &out}
+
+	res := output.NewResult("")
+	if err := run(context.Background(), client, "org", "rules", cfg, opts, res); err != nil {
+		t.Fatalf("移行に失敗: %v", err)
+	}
+
+	// 移動先に既にあるファイルは上書きしない
+	if len(fake.written) != 1 || fake.written["org-a/api/rules.md"] != "# API rules\n" {
+		t.Errorf("移動先に書き込まれた内容が一致しません: %v", fake.written)
+	}
+	sort.Strings(fake.deleted)
+	if len(fake.deleted) != 1 || fake.deleted[0] != "api/rules.md" {
+		t.Errorf("移動元が削除されていません: %v", fake.deleted)
+	}
+	if fake.pulls != 1 {
+		t.Errorf("PRが作成されていません")
+	}
+
+	// 移動と削除、スキップした理由を結果に記録する
+	want := []output.File{
+		{RemotePath: "org-a/api/rules.md", SHA: gitutil.BlobSHA([]byte("# API rules\n")), Status: output.StatusCommitted},
+		{RemotePath: "api/rules.md", Status: output.StatusDeleted},
+	}
+	if !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("結果のファイル: 期待値 %+v, 実際の値 %+v", want, res.Files)
+	}
+	if res.PullRequest == nil || res.PullRequest.Number != 1 || res.Branch != "migrate-layout-org-a-api" {
+		t.Errorf("結果のPRとブランチが期待と異なります: %+v, %s", res.PullRequest, res.Branch)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "org-a/api/CLAUDE.md") {
+		t.Errorf("移動しなかったファイルの警告が記録されていません: %v", res.Warnings)
+	}
+	for _, want := range []string{"api/rules.md -> org-a/api/rules.md", "layout: owner"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunKeepsFlatFiles(t *testing.T) {
+	fake := newFakeRepo()
+	client := newTestClient(t, fake)
+
+	cfg := &config.Config{Files: []string{"rules.md"}, RepoOwner: "org-a", RepoName: "api"}
+	opts := Options{Keep: true, Yes: true, Out: io.Discard}
+
+	if err := run(context.Background(), client, "org", "rules", cfg, opts, output.NewResult("")); err != nil {
+		t.Fatalf("移行に失敗: %v", err)
+	}
+	if len(fake.written) != 1 || len(fake.deleted) != 0 {
+		t.Errorf("--keep で移動元が削除されました: 書き込み %v, 削除 %v", fake.written, fake.deleted)
+	}
+}
+
+func TestRunAbortsWithoutConfirmation(t *testing.T) {
+	fake := newFakeRepo()
+	client := newTestClient(t, fake)
+
+	cfg := &config.Config{Files: []string{"rules.md"}, RepoOwner: "org-a", RepoName: "api"}
+	opts := Options{In: strings.NewReader("n\n"), Out: io.Discard}
+
+	if err := run(context.Background(), client, "org", "rules", cfg, opts, output.NewResult("")); err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if len(fake.written) != 0 || fake.pulls != 0 {
+		t.Errorf("確認を拒否したのに変更されました: %v", fake.written)
+	}
+}
+
+func TestRunRequiresOwner(t *testing.T) {
+	client := newTestClient(t, newFakeRepo())
+
+	cfg := &config.Config{Files: []string{"rules.md"}, RepoName: "api"}
+	err := run(context.Background(), client, "org", "rules", cfg, Options{Yes: true, Out: io.Discard}, output.NewResult(""))
+	if err == nil || !strings.Contains(err.Error(), "repo-owner") {
+		t.Errorf("所有者が不明な場合にエラーになりませんでした: %v", err)
+	}
+}
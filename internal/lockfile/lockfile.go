@@ -0,0 +1,148 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+)
+
+// FileName はプロジェクトの local-dir に置くロックファイルの名前
+const FileName = ".ruleforge.lock"
+
+// currentVersion はロックファイルの形式のバージョン
+const currentVersion = 1
+
+// ファイルを記録した操作
+const (
+	// ベースリポジトリからダウンロードした
+	SourceDownload = "download"
+	// ベースリポジトリにアップロードした
+	SourceUpload = "upload"
+)
+
+// Lockfile はローカルのルールファイルとベースリポジトリで最後に一致していた内容の記録
+// チームで共有できるよう、時刻などの実行ごとに変わる値は含めない
+type Lockfile struct {
+	Version int `json:"version"`
+
+	// 記録したときのベースリポジトリ
+	BaseRepo string `json:"base-repo"`
+
+	// local-dir からの相対パスごとの記録
+	Files map[string]*Entry `json:"files"`
+}
+
+// Entry は1ファイル分の記録
+type Entry struct {
+	// ベースリポジトリ上のパス
+	RemotePath string `json:"remote-path"`
+
+	// 内容の git blob SHA
+	SHA string `json:"sha"`
+
+	// 記録した操作（download または upload）
+	Source string `json:"source"`
+}
+
+// Path はプロジェクトのロックファイルのパスを返す
+func Path(cfg *config.Config) string {
+	return filepath.Join(cfg.LocalDir, FileName)
+}
+
+// Load はロックファイルを読み込む
+// ファイルが存在しない場合は空のロックファイルを返す
+func Load(path string) (*Lockfile, error) {
+	lock := &Lockfile{Version: currentVersion, Files: map[string]*Entry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロックファイル '%s' の読み込みに失敗: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("ロックファイル '%s' の解析に失敗: %w", path, err)
+	}
+	if lock.Version > currentVersion {
+		return nil, fmt.Errorf("ロックファイル '%s' のバージョン %d には対応していません。ruleforge を更新してください", path, lock.Version)
+	}
+	if lock.Files == nil {
+		lock.Files = map[string]*Entry{}
+	}
+	return lock, nil
+}
+
+// Update はロックファイルを読み込んで update で変更し、書き込む
+// ロックファイルが存在しない場合は新しく作成する
+func Update(path, baseRepo string, update func(lock *Lockfile)) error {
+	lock, err := Load(path)
+	if err != nil {
+		return err
+	}
+	lock.Version = currentVersion
+	lock.BaseRepo = baseRepo
+	update(lock)
+	return lock.Save(path)
+}
+
+// Set はファイルの内容を記録する
+func (l *Lockfile) Set(filePath, remotePath string, content []byte, source string) {
+	l.Files[filePath] = &Entry{RemotePath: remotePath, SHA: gitutil.BlobSHA(content), Source: source}
+}
+
+// Save はロックファイルを書き込む（書き込み途中で中断されても壊れないよう一時ファイル経由で置き換える）
+func (l *Lockfile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ロックファイルの変換に失敗: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("ロックファイルの作成に失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ロックファイルの書き込みに失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("ロックファイルの書き込みに失敗: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("ロックファイル '%s' の更新に失敗: %w", path, err)
+	}
+	return nil
+}
+
+// Diverged はローカルの内容がロックファイルの記録と異なる対象ファイルを返す
+// ロックファイルに記録のないファイルも異なるものとして扱い、ローカルに存在しないファイルは対象外とする
+func (l *Lockfile) Diverged(cfg *config.Config) ([]string, error) {
+	var diverged []string
+	for _, filePath := range cfg.Files {
+		content, err := os.ReadFile(filepath.Join(cfg.LocalDir, filePath))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ファイル '%s' の読み込みに失敗: %w", filePath, err)
+		}
+
+		if entry := l.Files[filePath]; entry == nil || entry.SHA != gitutil.BlobSHA(content) {
+			diverged = append(diverged, filePath)
+		}
+	}
+	sort.Strings(diverged)
+	return diverged, nil
+}
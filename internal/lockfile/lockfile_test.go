@@ -0,0 +1,72 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+func TestUpdateAndDiverged(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{LocalDir: dir, Files: []string{"a.md", "b.md", "c.md", "missing.md"}}
+	for name, content := range map[string]string{"a.md": "a", "b.md": "b", "c.md": "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 存在しないロックファイルは空として扱い、新しく作成する
+	err := Update(Path(cfg), "org/rules", func(lock *Lockfile) {
+		lock.Set("a.md", "a.md", []byte("a"), SourceDownload)
+		lock.Set("b.md", "svc/b.md", []byte("old b"), SourceDownload)
+	})
+	if err != nil {
+		t.Fatalf("ロックファイルの更新に失敗: %v", err)
+	}
+
+	lock, err := Load(Path(cfg))
+	if err != nil {
+		t.Fatalf("ロックファイルの読み込みに失敗: %v", err)
+	}
+	if lock.BaseRepo != "org/rules" || lock.Files["b.md"].RemotePath != "svc/b.md" || lock.Files["b.md"].Source != SourceDownload {
+		t.Errorf("ロックファイルの内容が期待と異なります: %+v", lock)
+	}
+
+	// 内容の異なるファイルと記録のないファイルが対象で、ローカルにないファイルは対象外
+	diverged, err := lock.Diverged(cfg)
+	if err != nil {
+		t.Fatalf("比較に失敗: %v", err)
+	}
+	if want := []string{"b.md", "c.md"}; !reflect.DeepEqual(diverged, want) {
+		t.Errorf("期待値 %v, 実際の値 %v", want, diverged)
+	}
+
+	// 既存の記録は残したまま更新する
+	err = Update(Path(cfg), "org/rules", func(lock *Lockfile) {
+		lock.Set("b.md", "svc/b.md", []byte("b"), SourceUpload)
+		lock.Set("c.md", "svc/c.md", []byte("c"), SourceUpload)
+	})
+	if err != nil {
+		t.Fatalf("ロックファイルの更新に失敗: %v", err)
+	}
+	lock, err = Load(Path(cfg))
+	if err != nil {
+		t.Fatalf("ロックファイルの読み込みに失敗: %v", err)
+	}
+	if diverged, err := lock.Diverged(cfg); err != nil || len(diverged) != 0 {
+		t.Errorf("更新後に異なるファイルがあります: %v, %v", diverged, err)
+	}
+}
+
+func TestLoadNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(`{"version": 99, "files": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("対応していないバージョンのエラーが期待されましたが、成功しました")
+	}
+}
@@ -0,0 +1,173 @@
+package mdsection
+
+import (
+	"strings"
+)
+
+// Section は見出し行から、次の同レベル以上の見出しの直前までの範囲
+// 下位レベルの見出しは親のセクションに含まれる
+type Section struct {
+	// 見出しのテキスト（"#" と前後の空白を除いたもの）
+	Title string
+
+	// 見出しのレベル（"#" の数）
+	Level int
+
+	// 見出し行を含むセクション全体のテキスト
+	Content string
+
+	// 文書内の行範囲 [start, end)
+	start, end int
+}
+
+// Parse はMarkdownの内容を見出しごとのセクションに分割する
+// コードブロック内の "#" で始まる行は見出しとして扱わない
+func Parse(content []byte) []Section {
+	lines := splitLines(string(content))
+
+	type heading struct {
+		line  int
+		level int
+		title string
+	}
+	var headings []heading
+	var fence string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if level, title, ok := parseHeading(line); ok {
+			headings = append(headings, heading{line: i, level: level, title: title})
+		}
+	}
+
+	sections := make([]Section, 0, len(headings))
+	for i, h := range headings {
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.level <= h.level {
+				end = next.line
+				break
+			}
+		}
+		sections = append(sections, Section{
+			Title:   h.title,
+			Level:   h.level,
+			Content: strings.Join(lines[h.line:end], ""),
+			start:   h.line,
+			end:     end,
+		})
+	}
+	return sections
+}
+
+// Find は指定した見出しのセクションを返す（大文字小文字と前後の空白は区別しない）
+// 同じ見出しが複数ある場合は最初のものを返す
+func Find(content []byte, title string) (Section, bool) {
+	for _, s := range Parse(content) {
+		if sameTitle(s.Title, title) {
+			return s, true
+		}
+	}
+	return Section{}, false
+}
+
+// Upsert は同じ見出しのセクションがあれば置き換え、なければ末尾に追加した内容を返す
+func Upsert(content []byte, section Section) []byte {
+	text := strings.TrimRight(section.Content, "\n") + "\n"
+
+	existing, ok := Find(content, section.Title)
+	if !ok {
+		doc := string(content)
+		if strings.TrimSpace(doc) == "" {
+			return []byte(text)
+		}
+		// 直前の内容と空行で区切る
+		doc = strings.TrimRight(doc, "\n") + "\n\n"
+		return []byte(doc + text)
+	}
+
+	lines := splitLines(string(content))
+	// 置き換え前のセクション末尾の空行を維持し、次のセクションとの区切りを保つ
+	trailing := 0
+	for i := existing.end - 1; i > existing.start && strings.TrimSpace(lines[i]) == ""; i-- {
+		trailing++
+	}
+	replaced := text + strings.Repeat("\n", trailing)
+
+	return []byte(strings.Join(lines[:existing.start], "") + replaced + strings.Join(lines[existing.end:], ""))
+}
+
+// Remove は指定した見出しのセクションを取り除いた内容を返す
+// セクションが見つからない場合は false を返す
+func Remove(content []byte, title string) ([]byte, bool) {
+	existing, ok := Find(content, title)
+	if !ok {
+		return content, false
+	}
+
+	lines := splitLines(string(content))
+	return []byte(strings.Join(lines[:existing.start], "") + strings.Join(lines[existing.end:], "")), true
+}
+
+// Titles は見出しの一覧をインデント付きで返す（選択肢の表示用）
+func Titles(sections []Section) []string {
+	titles := make([]string, len(sections))
+	for i, s := range sections {
+		titles[i] = strings.Repeat("  ", s.Level-1) + s.Title
+	}
+	return titles
+}
+
+// parseHeading はATX形式の見出し行（"## タイトル"）を解析する
+func parseHeading(line string) (int, string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	// 4つ以上のスペースでインデントされた行はコードブロック
+	if strings.HasPrefix(line, "    ") {
+		return 0, "", false
+	}
+	line = strings.TrimLeft(line, " ")
+
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+
+	// 末尾の閉じ "#" を除く
+	title := strings.TrimSpace(rest)
+	if trimmed := strings.TrimRight(title, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		title = strings.TrimSpace(trimmed)
+	}
+	return level, title, true
+}
+
+func sameTitle(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// splitLines は改行を含めたまま行に分割する
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,105 @@
+package mdsection
+
+import "testing"
+
+const general = `# General rules
+
+Intro.
+
+## Style
+
+Use gofmt.
+
+### Naming
+
+Short names.
+
+## Testing
+
+Write tests.
+`
+
+func TestParse(t *testing.T) {
+	sections := Parse([]byte(general + "\n```md\n## Not a heading\n```\n"))
+
+	expected := []struct {
+		title string
+		level int
+	}{
+		{"General rules", 1},
+		{"Style", 2},
+		{"Naming", 3},
+		{"Testing", 2},
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("セクション数が一致しません: %+v", sections)
+	}
+	for i, e := range expected {
+		if sections[i].Title != e.title || sections[i].Level != e.level {
+			t.Errorf("セクション %d: 期待値 %s (%d), 実際の値 %s (%d)", i, e.title, e.level, sections[i].Title, sections[i].Level)
+		}
+	}
+
+	// 下位の見出しは親のセクションに含まれる
+	if want := "## Style\n\nUse gofmt.\n\n### Naming\n\nShort names.\n\n"; sections[1].Content != want {
+		t.Errorf("Style セクションの内容が一致しません: %q", sections[1].Content)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		section  string
+		expected string
+	}{
+		{
+			name:    "既存のセクションを置き換える",
+			section: "## style\n\nUse goimports.\n",
+			expected: `# General rules
+
+Intro.
+
+## style
+
+Use goimports.
+
+## Testing
+
+Write tests.
+`,
+		},
+		{
+			name:    "新しいセクションを末尾に追加する",
+			section: "## Error handling\n\nWrap errors.\n\n",
+			expected: general + `
+## Error handling
+
+Wrap errors.
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section := Parse([]byte(tt.section))[0]
+			if got := string(Upsert([]byte(general), section)); got != tt.expected {
+				t.Errorf("結果が一致しません:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	got, ok := Remove([]byte(general), "Style")
+	if !ok {
+		t.Fatalf("セクションが見つかりません")
+	}
+	expected := "# General rules\n\nIntro.\n\n## Testing\n\nWrite tests.\n"
+	if string(got) != expected {
+		t.Errorf("結果が一致しません:\n%s", got)
+	}
+
+	if _, ok := Remove([]byte(general), "Unknown"); ok {
+		t.Errorf("存在しないセクションが削除されました")
+	}
+}
@@ -0,0 +1,199 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+)
+
+// 出力形式
+const (
+	// ログと表による人が読むための出力
+	FormatText = "text"
+	// 標準出力に1つの JSON オブジェクトを出力（ログは標準エラー出力のまま）
+	FormatJSON = "json"
+)
+
+// ファイルごとの処理結果
+const (
+	StatusDownloaded = "downloaded"
+	StatusCommitted  = "committed"
+	StatusUnchanged  = "unchanged"
+	StatusDeleted    = "deleted"
+	StatusSkipped    = "skipped"
+	StatusFailed     = "failed"
+	StatusGenerated  = "generated"
+	StatusSelected   = "selected"
+	StatusInstalled  = "installed"
+	StatusRemoved    = "removed"
+	StatusRestored   = "restored"
+	StatusMigrated   = "migrated"
+	StatusOutdated   = "outdated"
+)
+
+// ValidateFormat は出力形式が対応しているかを確認する
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("出力形式 %q には対応していません（%s または %s を指定してください）", format, FormatText, FormatJSON)
+	}
+}
+
+// Report は --output json で出力する1回のコマンド実行の結果
+type Report struct {
+	// 実行したコマンド（例: "download"、"layout migrate"）
+	Command string `json:"command"`
+
+	// コマンドが成功した場合は true
+	OK bool `json:"ok"`
+
+	// 失敗した場合のエラー
+	Error string `json:"error,omitempty"`
+
+	// プロジェクト（fleet sync では配布先リポジトリ）ごとの結果
+	Results []*Result `json:"results"`
+}
+
+// Result は1プロジェクト分の処理結果
+type Result struct {
+	// モノレポのプロジェクトのパス、または fleet sync の配布先リポジトリ
+	Project string `json:"project,omitempty"`
+
+	// 処理したファイル
+	Files []File `json:"files"`
+
+	// コミットした作業用ブランチ
+	Branch string `json:"branch,omitempty"`
+
+	// 作成または更新したプルリクエスト
+	PullRequest *PullRequest `json:"pull-request,omitempty"`
+
+	// 処理は続けたが利用者に伝える必要のある問題
+	Warnings []string `json:"warnings"`
+
+	// ホストの認証情報（login、logout、auth status）
+	Auth *Auth `json:"auth,omitempty"`
+
+	// 有効な設定（config show、トークンはマスクする）
+	Config map[string]any `json:"config,omitempty"`
+
+	// 設定値ごとの取得元（config show --origin）
+	Origins map[string]string `json:"origins,omitempty"`
+
+	// 設定の問題（config validate）
+	Problems []Problem `json:"problems,omitempty"`
+
+	// 設定ファイルに適用した（--diff の場合は適用する）変更（config migrate）
+	Migrations []string `json:"migrations,omitempty"`
+
+	// このプロジェクトで失敗した場合のエラー
+	Error string `json:"error,omitempty"`
+}
+
+// File は1ファイル分の処理結果
+type File struct {
+	// ローカルのパス
+	Path string `json:"path,omitempty"`
+
+	// ベースリポジトリ（またはプルリクエストの対象リポジトリ）上のパス
+	RemotePath string `json:"remote-path,omitempty"`
+
+	// 内容の git blob SHA
+	SHA string `json:"sha,omitempty"`
+
+	// 処理結果（downloaded、committed、drifted など）
+	Status string `json:"status"`
+
+	// このファイルで失敗した場合のエラー
+	Error string `json:"error,omitempty"`
+}
+
+// PullRequest は作成または更新したプルリクエスト
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+
+	// 既存のプルリクエストにコミットを追加した場合は true
+	Existed bool `json:"existed"`
+
+	// ローカルの内容がベースリポジトリと同じになったため、既存のプルリクエストを閉じた場合は true
+	Closed bool `json:"closed"`
+}
+
+// Auth はホストの認証情報
+type Auth struct {
+	// 対象のホスト
+	Host string `json:"host"`
+
+	// ログインしたユーザー（login）
+	User string `json:"user,omitempty"`
+
+	// 使用するトークンの取得元（auth status、見つからない場合は空）
+	TokenSource string `json:"token-source,omitempty"`
+
+	// 認証情報ファイルのパス
+	CredentialsFile string `json:"credentials-file,omitempty"`
+
+	// ログイン済みのホスト（auth status）
+	LoggedIn []Login `json:"logged-in,omitempty"`
+}
+
+// Login は認証情報ファイルに保存したログイン
+type Login struct {
+	Host string `json:"host"`
+	User string `json:"user,omitempty"`
+}
+
+// Problem は設定の1件の問題
+type Problem struct {
+	// 問題のある設定のキー
+	Key string `json:"key,omitempty"`
+
+	// 値の指定箇所（設定ファイルのパスと行番号、環境変数名、フラグ名など）
+	Location string `json:"location,omitempty"`
+
+	// 問題の内容
+	Message string `json:"message"`
+}
+
+// NewResult は空の結果を作成する（JSON で files と warnings が null にならないよう空のスライスで初期化する）
+func NewResult(project string) *Result {
+	return &Result{Project: project, Files: []File{}, Warnings: []string{}}
+}
+
+// Warn は警告をログに出力し、結果に記録する
+func (r *Result) Warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("警告: %s", msg)
+	r.Warnings = append(r.Warnings, msg)
+}
+
+// Fail はエラーを結果に記録し、そのまま返す
+func (r *Result) Fail(err error) error {
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return err
+}
+
+// Write はコマンドの結果を JSON で書き込む
+func Write(w io.Writer, command string, results []*Result, err error) error {
+	report := Report{Command: command, OK: err == nil, Results: results}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if report.Results == nil {
+		report.Results = []*Result{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("結果の JSON への変換に失敗: %w", err)
+	}
+	return nil
+}
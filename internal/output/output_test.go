@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	res := NewResult("billing")
+	res.Files = append(res.Files, File{Path: "AGENTS.md", RemotePath: "app/billing/AGENTS.md", SHA: "abc", Status: StatusCommitted})
+	res.Branch = "update-rules"
+	res.PullRequest = &PullRequest{Number: 7, URL: "https://github.com/org/rules/pull/7"}
+
+	var out bytes.Buffer
+	if err := Write(&out, "upload", []*Result{res, NewResult("web")}, errors.New("web: 失敗しました")); err != nil {
+		t.Fatalf("書き込みに失敗: %v", err)
+	}
+	for _, want := range []string{
+		`"command": "upload"`,
+		`"ok": false`,
+		`"error": "web: 失敗しました"`,
+		`"remote-path": "app/billing/AGENTS.md"`,
+		`"pull-request": {`,
+		`"url": "https://github.com/org/rules/pull/7"`,
+		// 空の結果も null ではなく空の配列にする
+		`"files": [],`,
+		`"warnings": []`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := Write(&out, "download", nil, nil); err != nil {
+		t.Fatalf("書き込みに失敗: %v", err)
+	}
+	if want := "{\n  \"command\": \"download\",\n  \"ok\": true,\n  \"results\": []\n}\n"; out.String() != want {
+		t.Errorf("期待値 %q, 実際の値 %q", want, out.String())
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+	}
+	if err := ValidateFormat("yaml"); err == nil {
+		t.Error("yaml でエラーが期待されました")
+	}
+}
@@ -0,0 +1,41 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+// Run は各プロジェクトで fn を順に実行する
+// 複数のプロジェクトがある場合はプロジェクトごとに見出しを、最後に成功と失敗の件数を出力する
+// いずれかのプロジェクトが失敗しても残りのプロジェクトは実行し、失敗をまとめて返す
+func Run(ctx context.Context, projects []*config.Project, fn func(ctx context.Context, p *config.Project) error) error {
+	if len(projects) == 1 {
+		return fn(ctx, projects[0])
+	}
+
+	var failed []string
+	var errs []error
+	for _, p := range projects {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("プロジェクト %s の前で中断しました: %w", p.Path, err)
+		}
+
+		log.Printf("=== %s ===", p.Path)
+		if err := fn(ctx, p); err != nil {
+			log.Printf("エラー: %s: %v", p.Path, err)
+			failed = append(failed, p.Path)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Path, err))
+		}
+	}
+
+	log.Printf("%d 件のプロジェクトのうち %d 件が成功しました", len(projects), len(projects)-len(failed))
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 件のプロジェクトで失敗しました（%s）: %w", len(failed), strings.Join(failed, ", "), errors.Join(errs...))
+	}
+	return nil
+}
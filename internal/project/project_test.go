@@ -0,0 +1,60 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+func TestRunContinuesAfterFailure(t *testing.T) {
+	projects := []*config.Project{
+		{Path: "apps/web", Config: &config.Config{}},
+		{Path: "services/billing", Config: &config.Config{}},
+		{Path: "services/search", Config: &config.Config{}},
+	}
+
+	var ran []string
+	err := Run(context.Background(), projects, func(ctx context.Context, p *config.Project) error {
+		ran = append(ran, p.Path)
+		if p.Path == "services/billing" {
+			return errors.New("ダウンロードに失敗")
+		}
+		return nil
+	})
+
+	if len(ran) != 3 {
+		t.Errorf("失敗の後のプロジェクトが実行されていません: %v", ran)
+	}
+	if err == nil || !strings.Contains(err.Error(), "1 件のプロジェクトで失敗") || !strings.Contains(err.Error(), "services/billing: ダウンロードに失敗") {
+		t.Errorf("失敗したプロジェクトが報告されていません: %v", err)
+	}
+}
+
+func TestRunSingleProject(t *testing.T) {
+	want := errors.New("失敗")
+	projects := []*config.Project{{Path: ".", Config: &config.Config{}}}
+
+	// プロジェクトが1つの場合はエラーをそのまま返す
+	err := Run(context.Background(), projects, func(ctx context.Context, p *config.Project) error { return want })
+	if err != want {
+		t.Errorf("エラー: 期待値 %v, 実際の値 %v", want, err)
+	}
+}
+
+func TestRunStopsWhenCanceled(t *testing.T) {
+	projects := []*config.Project{{Path: "a", Config: &config.Config{}}, {Path: "b", Config: &config.Config{}}}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var ran int
+	err := Run(ctx, projects, func(ctx context.Context, p *config.Project) error {
+		ran++
+		cancel()
+		return nil
+	})
+	if ran != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("キャンセル後に実行されました: 実行数 %d, エラー %v", ran, err)
+	}
+}
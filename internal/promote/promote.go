@@ -0,0 +1,226 @@
+package promote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/diff"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/mdsection"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/prompt"
+	"github.com/hiroyannnn/ruleforge/internal/publish"
+)
+
+// Options は promote コマンドの実行オプション
+type Options struct {
+	// 昇格元のリポジトリ名（ベースリポジトリの <RepoName>/ ディレクトリ）
+	RepoName string
+
+	// 昇格するセクションの見出し（空の場合はファイル全体）
+	Sections []string
+
+	// 昇格したルールをリポジトリのディレクトリから削除する
+	Remove bool
+
+	// 確認せずにプルリクエストを作成する
+	Yes bool
+
+	// 確認の入力と差分の出力先（nilの場合は標準入出力）
+	In  io.Reader
+	Out io.Writer
+}
+
+// change はベースリポジトリの1ファイル分の変更
+type change struct {
+	path    string
+	old     []byte
+	new     []byte
+	deleted bool
+}
+
+// Execute はリポジトリ固有のルールを general に昇格するプルリクエストを作成する
+// 失敗した場合も途中までの結果を返す
+func Execute(ctx context.Context, cfg *config.Config, opts Options) (*output.Result, error) {
+	res := output.NewResult("")
+	if !cfg.HasCredentials() {
+		return res, res.Fail(fmt.Errorf("GitHub APIトークンが設定されていません。環境変数 GITHUB_TOKEN を設定するか、設定ファイルで指定してください"))
+	}
+
+	if opts.RepoName == "" {
+		return res, res.Fail(fmt.Errorf("昇格元のリポジトリ名が指定されていません"))
+	}
+
+	owner, repo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
+	}
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
+	}
+
+	return res, res.Fail(run(ctx, client, owner, repo, cfg, opts, res))
+}
+
+func run(ctx context.Context, client *github.Client, owner, repo string, cfg *config.Config, opts Options, res *output.Result) error {
+	in, out := opts.In, opts.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("リポジトリ情報の取得に失敗: %w", err)
+	}
+
+	changes, err := plan(ctx, client, owner, repo, repository.GetDefaultBranch(), cfg.Files, opts, res)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		log.Printf("general と内容が同じため、昇格する変更はありません")
+		return nil
+	}
+
+	// PRを作成する前に差分を表示する
+	var files []publish.File
+	var deletions []string
+	for _, c := range changes {
+		oldName, newName := "a/"+c.path, "b/"+c.path
+		if c.old == nil {
+			oldName = "/dev/null"
+		}
+		if c.deleted {
+			newName = "/dev/null"
+			deletions = append(deletions, c.path)
+		} else {
+			files = append(files, publish.File{Path: c.path, Content: c.new})
+		}
+		fmt.Fprint(out, diff.Unified(oldName, newName, string(c.old), string(c.new)))
+	}
+
+	if !opts.Yes {
+		ok, err := prompt.New(in, out).Confirm("この内容でプルリクエストを作成しますか?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "中止しました")
+			return nil
+		}
+	}
+
+	message := cfg.Message
+	if message == "" {
+		message = fmt.Sprintf("Promote %s rules to general", opts.RepoName)
+	}
+
+	result, err := publish.Run(ctx, client, &publish.Request{
+		Owner:       owner,
+		Repo:        repo,
+		Branch:      fmt.Sprintf("promote-%s-%s", opts.RepoName, cfg.BranchName),
+		Files:       files,
+		Deletions:   deletions,
+		Message:     message,
+		Title:       "[General] " + message,
+		Body:        pullRequestBody(opts),
+		PullRequest: cfg.PullRequest,
+		Verbose:     cfg.Verbose,
+	})
+	if err != nil {
+		return err
+	}
+	result.Record(res, files)
+	return nil
+}
+
+// plan は昇格によるファイルの変更を求める
+func plan(ctx context.Context, client *github.Client, owner, repo, ref string, targetFiles []string, opts Options, res *output.Result) ([]change, error) {
+	var changes []change
+	found := map[string]bool{}
+
+	for _, filePath := range targetFiles {
+		srcPath := path.Join(opts.RepoName, filePath)
+		genPath := path.Join("general", filePath)
+
+		src, err := ghclient.GetFile(ctx, client, owner, repo, srcPath, ref)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil {
+			if len(opts.Sections) == 0 {
+				res.Warn("ファイル '%s' が見つかりません。スキップします", srcPath)
+			}
+			continue
+		}
+		gen, err := ghclient.GetFile(ctx, client, owner, repo, genPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		// セクションの指定がなければファイル全体を昇格する
+		newGen, newSrc := src, []byte(nil)
+		if len(opts.Sections) > 0 {
+			newGen, newSrc = gen, src
+			promoted := false
+			for _, title := range opts.Sections {
+				section, ok := mdsection.Find(src, title)
+				if !ok {
+					continue
+				}
+				found[title] = true
+				promoted = true
+				newGen = mdsection.Upsert(newGen, section)
+				newSrc, _ = mdsection.Remove(newSrc, title)
+			}
+			if !promoted {
+				continue
+			}
+			// セクションを取り除いて空になった場合はファイルごと削除する
+			if strings.TrimSpace(string(newSrc)) == "" {
+				newSrc = nil
+			}
+		}
+
+		if string(newGen) != string(gen) {
+			changes = append(changes, change{path: genPath, old: gen, new: newGen})
+		}
+		if opts.Remove {
+			changes = append(changes, change{path: srcPath, old: src, new: newSrc, deleted: newSrc == nil})
+		}
+	}
+
+	for _, title := range opts.Sections {
+		if !found[title] {
+			return nil, fmt.Errorf("セクション '%s' が %s/ のファイルに見つかりません", title, opts.RepoName)
+		}
+	}
+
+	return changes, nil
+}
+
+func pullRequestBody(opts Options) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s のルールを general に昇格します。\n", opts.RepoName)
+	if len(opts.Sections) > 0 {
+		sb.WriteString("\n対象のセクション:\n")
+		for _, title := range opts.Sections {
+			fmt.Fprintf(&sb, "- %s\n", title)
+		}
+	}
+	if opts.Remove {
+		fmt.Fprintf(&sb, "\n昇格したルールは %s/ から削除します。\n", opts.RepoName)
+	}
+	return sb.String()
+}
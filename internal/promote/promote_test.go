@@ -0,0 +1,169 @@
+package promote
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// fakeRepo はベースリポジトリのファイルとブランチ・PR作成だけを扱う簡易的なGitHub APIのモック
+type fakeRepo struct {
+	mu      sync.Mutex
+	files   map[string]string // パス -> 内容（デフォルトブランチ）
+	written map[string]string // 作業用ブランチに書き込まれた内容
+	deleted []string
+	pulls   int
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	p := strings.TrimPrefix(r.URL.Path, "/repos/org/rules")
+
+	switch {
+	case p == "" && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+
+	case strings.HasPrefix(p, "/git/ref/") && r.Method == "GET":
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`))
+
+	case p == "/git/refs" && r.Method == "POST":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/branch"}`))
+
+	case strings.HasPrefix(p, "/contents/") && r.Method == "GET":
+		filePath := strings.TrimPrefix(p, "/contents/")
+		content, ok := f.files[filePath]
+		if !ok || r.URL.Query().Get("ref") != "main" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"sha":      "sha-" + filePath,
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case strings.HasPrefix(p, "/contents/") && r.Method == "PUT":
+		var body struct {
+			Content []byte `json:"content"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		f.written[strings.TrimPrefix(p, "/contents/")] = string(body.Content)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"content": {}}`))
+
+	case p == "/pulls" && r.Method == "POST":
+		f.pulls++
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 1}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("URLの解析に失敗: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		files: map[string]string{
+			"app/rules.md":     "# App rules\n\n## Style\n\nUse gofmt.\n\n## Deploy\n\nUse app deploy.\n",
+			"general/rules.md": "# General rules\n\n## Testing\n\nWrite tests.\n",
+		},
+		written: map[string]string{},
+	}
+}
+
+func TestRunPromotesSection(t *testing.T) {
+	fake := newFakeRepo()
+	client := newTestClient(t, fake)
+
+	var out bytes.Buffer
+	cfg := &config.Config{Files: []string{"rules.md"}, BranchName: "update-agent-rules"}
+	opts := Options{RepoName: "app", Sections: []string{"Style"}, Remove: true, In: strings.NewReader("y\n"), Out: &out}
+
+	if err := run(context.Background(), client, "org", "rules", cfg, opts, output.NewResult("")); err != nil {
+		t.Fatalf("昇格に失敗: %v", err)
+	}
+
+	expectedGeneral := "# General rules\n\n## Testing\n\nWrite tests.\n\n## Style\n\nUse gofmt.\n"
+	if got := fake.written["general/rules.md"]; got != expectedGeneral {
+		t.Errorf("general の内容が一致しません:\n%s", got)
+	}
+	expectedSource := "# App rules\n\n## Deploy\n\nUse app deploy.\n"
+	if got := fake.written["app/rules.md"]; got != expectedSource {
+		t.Errorf("昇格元からセクションが削除されていません:\n%s", got)
+	}
+	if fake.pulls != 1 {
+		t.Errorf("PRが作成されていません")
+	}
+
+	// PRを作成する前に差分が表示される
+	for _, want := range []string{"--- a/general/rules.md", "+## Style", "--- a/app/rules.md", "-Use gofmt."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("差分に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunAbortsWithoutConfirmation(t *testing.T) {
+	fake := newFakeRepo()
+	client := newTestClient(t, fake)
+
+	var out bytes.Buffer
+	cfg := &config.Config{Files: []string{"rules.md"}, BranchName: "update-agent-rules"}
+	opts := Options{RepoName: "app", In: strings.NewReader("n\n"), Out: &out}
+
+	if err := run(context.Background(), client, "org", "rules", cfg, opts, output.NewResult("")); err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if len(fake.written) != 0 || fake.pulls != 0 {
+		t.Errorf("確認を拒否したのに変更されました: %v", fake.written)
+	}
+	if !strings.Contains(out.String(), "+# App rules") {
+		t.Errorf("ファイル全体の差分が表示されていません:\n%s", out.String())
+	}
+}
+
+func TestRunUnknownSection(t *testing.T) {
+	client := newTestClient(t, newFakeRepo())
+
+	cfg := &config.Config{Files: []string{"rules.md"}}
+	opts := Options{RepoName: "app", Sections: []string{"Unknown"}, Yes: true, Out: io.Discard}
+
+	err := run(context.Background(), client, "org", "rules", cfg, opts, output.NewResult(""))
+	if err == nil || !strings.Contains(err.Error(), "Unknown") {
+		t.Errorf("存在しないセクションでエラーになりませんでした: %v", err)
+	}
+}
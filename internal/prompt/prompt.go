@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Prompter は対話的な確認や選択を行う
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New は入力と出力を指定して Prompter を生成する
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// Confirm は y/N の確認を行う。入力が終端に達した場合は false を返す
+func (p *Prompter) Confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N]: ", question)
+	answer, err := p.readLine()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// Ask は1行の入力を求める。何も入力しなければ defaultValue を返す
+func (p *Prompter) Ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Select は番号付きの選択肢を表示し、選択された項目のインデックスを返す
+// 番号はカンマまたは空白区切りで複数指定でき、何も入力しなければ空を返す
+func (p *Prompter) Select(question string, items []string) ([]int, error) {
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, item)
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s（番号をカンマ区切りで入力）: ", question)
+		answer, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		selected, err := parseSelection(answer, len(items))
+		if err == nil {
+			return selected, nil
+		}
+		fmt.Fprintf(p.out, "%v\n", err)
+	}
+}
+
+// parseSelection は "1,3 4" のような入力を0始まりのインデックスに変換する
+func parseSelection(answer string, n int) ([]int, error) {
+	fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+
+	seen := map[int]bool{}
+	var selected []int
+	for _, f := range fields {
+		i, err := strconv.Atoi(f)
+		if err != nil || i < 1 || i > n {
+			return nil, fmt.Errorf("1 から %d の番号を入力してください: %s", n, f)
+		}
+		if !seen[i] {
+			seen[i] = true
+			selected = append(selected, i-1)
+		}
+	}
+	return selected, nil
+}
+
+// readLine は1行を読み込み、前後の空白を除いて返す
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("入力の読み込みに失敗: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,85 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := New(strings.NewReader(tt.input), &out).Confirm("続行しますか?")
+		if err != nil {
+			t.Fatalf("入力 %q でエラー: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("入力 %q: 期待値 %v, 実際の値 %v", tt.input, tt.expected, got)
+		}
+		if !strings.Contains(out.String(), "続行しますか? [y/N]") {
+			t.Errorf("質問が表示されていません: %q", out.String())
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	items := []string{"Style", "Testing", "Deploy"}
+
+	// 不正な入力は再入力を求める
+	var out bytes.Buffer
+	got, err := New(strings.NewReader("4\n3, 1 3\n"), &out).Select("セクションを選択", items)
+	if err != nil {
+		t.Fatalf("選択に失敗: %v", err)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 0 {
+		t.Errorf("選択結果が一致しません: %v", got)
+	}
+	if !strings.Contains(out.String(), "2) Testing") || !strings.Contains(out.String(), "1 から 3 の番号") {
+		t.Errorf("選択肢またはエラーが表示されていません: %q", out.String())
+	}
+
+	// 入力がなければ何も選択しない
+	got, err = New(strings.NewReader(""), &out).Select("セクションを選択", items)
+	if err != nil || len(got) != 0 {
+		t.Errorf("空の入力: %v, %v", got, err)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		input        string
+		defaultValue string
+		expected     string
+	}{
+		{"org/rules\n", "", "org/rules"},
+		{"  org/rules  \n", "org/default", "org/rules"},
+		{"\n", "org/default", "org/default"},
+		{"", "org/default", "org/default"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := New(strings.NewReader(tt.input), &out).Ask("ベースリポジトリ", tt.defaultValue)
+		if err != nil {
+			t.Fatalf("入力 %q でエラー: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("入力 %q: 期待値 %q, 実際の値 %q", tt.input, tt.expected, got)
+		}
+		if tt.defaultValue != "" && !strings.Contains(out.String(), "["+tt.defaultValue+"]") {
+			t.Errorf("デフォルト値が表示されていません: %q", out.String())
+		}
+	}
+}
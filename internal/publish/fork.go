@@ -0,0 +1,75 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+)
+
+// フォークが利用可能になるまでの待機設定（テストで短縮できるよう変数にしている）
+var (
+	forkPollInterval = 2 * time.Second
+	forkWaitTimeout  = 2 * time.Minute
+)
+
+// forkRepository はベースリポジトリをフォーク（既にフォーク済みであれば再利用）し、
+// フォークのデフォルトブランチをベースリポジトリに同期する
+func forkRepository(ctx context.Context, client *github.Client, owner, repo, baseBranch string, result *Result) (string, string, error) {
+	// 既にフォーク済みの場合も GitHub は既存のフォークを返す
+	fork, _, err := client.Repositories.CreateFork(ctx, owner, repo, &github.RepositoryCreateForkOptions{DefaultBranchOnly: true})
+	var accepted *github.AcceptedError
+	if err != nil && !errors.As(err, &accepted) {
+		return "", "", fmt.Errorf("ベースリポジトリのフォークに失敗: %w", err)
+	}
+
+	forkOwner, forkRepo := fork.GetOwner().GetLogin(), fork.GetName()
+	if forkOwner == "" || forkRepo == "" {
+		return "", "", fmt.Errorf("フォークしたリポジトリの情報を取得できません")
+	}
+	log.Printf("フォーク %s/%s を使用します", forkOwner, forkRepo)
+
+	// フォークの作成は非同期に行われるため、ブランチが参照できるようになるまで待つ
+	if err := waitForFork(ctx, client, forkOwner, forkRepo, baseBranch); err != nil {
+		return "", "", err
+	}
+
+	// フォークのデフォルトブランチをベースリポジトリの最新に同期
+	// 作業用ブランチはベースリポジトリのコミットから作成するため、失敗しても処理は続ける
+	if _, _, err := client.Repositories.MergeUpstream(ctx, forkOwner, forkRepo, &github.RepoMergeUpstreamRequest{
+		Branch: github.String(baseBranch),
+	}); err != nil {
+		result.warn("フォークのブランチ '%s' の同期に失敗: %v", baseBranch, err)
+	}
+
+	return forkOwner, forkRepo, nil
+}
+
+// waitForFork はフォークのブランチが参照できるようになるまで待つ
+func waitForFork(ctx context.Context, client *github.Client, owner, repo, branch string) error {
+	deadline := time.Now().Add(forkWaitTimeout)
+	for {
+		_, _, err := client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+		if err == nil {
+			return nil
+		}
+		// 作成中のフォークは 404 または 409 (空のリポジトリ) を返す
+		var errResp *github.ErrorResponse
+		if !ghclient.IsNotFound(err) && !(errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == 409) {
+			return fmt.Errorf("フォーク %s/%s の確認に失敗: %w", owner, repo, err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("フォーク %s/%s の作成が %v 以内に完了しませんでした", owner, repo, forkWaitTimeout)
+		}
+
+		select {
+		case <-time.After(forkPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package publish
+
+import (
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// Record は送信したファイル、ブランチ、プルリクエスト、警告を out に記録する
+// files は Run に渡したファイルで、Source をローカルのパスとして記録する
+func (r *Result) Record(out *output.Result, files []File) {
+	committed := map[string]bool{}
+	for _, p := range r.Committed {
+		committed[p] = true
+	}
+
+	for _, f := range files {
+		status := output.StatusUnchanged
+		if committed[f.Path] {
+			status = output.StatusCommitted
+		}
+		out.Files = append(out.Files, output.File{Path: f.Source, RemotePath: f.Path, SHA: gitutil.BlobSHA(f.Content), Status: status})
+	}
+	for _, p := range r.Deleted {
+		out.Files = append(out.Files, output.File{RemotePath: p, Status: output.StatusDeleted})
+	}
+
+	if !r.NoChanges {
+		out.Branch = r.Branch
+	}
+	if r.PRNumber != 0 {
+		out.PullRequest = &output.PullRequest{Number: r.PRNumber, URL: r.PRURL, Existed: r.PRExisted, Closed: r.PRClosed}
+	}
+	out.Warnings = append(out.Warnings, r.Warnings...)
+}
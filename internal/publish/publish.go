@@ -0,0 +1,480 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+)
+
+// File はブランチにコミットするファイル
+type File struct {
+	// リポジトリ内のパス
+	Path string
+
+	// ファイルの内容
+	Content []byte
+
+	// ログ表示用のコピー元（ローカルパスなど、空の場合は Path を表示）
+	Source string
+}
+
+// Request はブランチの作成からプルリクエストの作成までに必要な情報
+type Request struct {
+	// 対象リポジトリの所有者とリポジトリ名
+	Owner string
+	Repo  string
+
+	// 作業用ブランチ名
+	Branch string
+
+	// コミットするファイル
+	Files []File
+
+	// 削除するファイル
+	Deletions []string
+
+	// 呼び出し元で対象外にした入力（ローカルにないファイルなど）がある場合は true
+	// Files がすべてベースブランチと同じでも、対象外の入力の変更が残っている可能性があるため既存のプルリクエストを閉じない
+	Partial bool
+
+	// 空でない場合、このディレクトリ配下で Files に含まれないファイルも削除する
+	SyncDir string
+
+	// SyncDir 配下でも削除しないディレクトリ（モノレポのほかのプロジェクトのディレクトリなど）
+	SyncExclude []string
+
+	// コミットメッセージ
+	Message string
+
+	// プルリクエストのタイトルと本文
+	Title string
+	Body  string
+
+	// ラベルやレビュアーなどプルリクエストに設定する追加情報
+	PullRequest config.PullRequestConfig
+
+	// 詳細なログ出力
+	Verbose bool
+}
+
+// Result はブランチとプルリクエストの作成結果
+type Result struct {
+	// プルリクエストのマージ先ブランチ
+	BaseBranch string
+
+	// 作業用ブランチ名
+	Branch string
+
+	// 今回の実行でブランチを作成したかどうか
+	BranchCreated bool
+
+	// 既存のブランチがベースブランチより遅れていたため、ベースブランチの最新に作り直した場合は true
+	BranchReset bool
+
+	// コミットしたファイルのパス
+	Committed []string
+
+	// 削除したファイルのパス
+	Deleted []string
+
+	// ベースブランチまたは作業用ブランチと内容が同じためコミットしなかったファイルのパス
+	Unchanged []string
+
+	// 変更がなく、ブランチもプルリクエストも作成しなかった場合は true
+	NoChanges bool
+
+	// プルリクエストの番号とURL
+	PRNumber int
+	PRURL    string
+
+	// 既存のプルリクエストにコミットを追加した場合は true
+	PRExisted bool
+
+	// ローカルの内容がベースブランチと同じになったため、既存のプルリクエストを閉じた場合は true
+	PRClosed bool
+
+	// 作業用ブランチを作成したリポジトリ（owner/repo 形式、フォーク経由の場合はフォーク）
+	HeadRepo string
+
+	// プッシュ権限がないためフォーク経由でプルリクエストを作成した場合は true
+	Forked bool
+
+	// 処理は続けたが利用者に伝える必要のある問題
+	Warnings []string
+}
+
+// warn は警告をログに出力し、結果に記録する
+func (r *Result) warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("警告: %s", msg)
+	r.Warnings = append(r.Warnings, msg)
+}
+
+// Run は作業用ブランチにファイルをコミットし、デフォルトブランチへのプルリクエストを作成する
+// 途中で失敗・中断した場合、今回作成したブランチは削除する
+func Run(ctx context.Context, client *github.Client, req *Request) (result *Result, err error) {
+	owner, repo := req.Owner, req.Repo
+	result = &Result{Branch: req.Branch}
+
+	// ベースブランチ（通常は main または master）を取得
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("リポジトリ情報の取得に失敗: %w", err)
+	}
+	result.BaseBranch = repository.GetDefaultBranch()
+
+	// ベースブランチのリファレンスを取得
+	baseRef, _, err := client.Git.GetRef(ctx, owner, repo, "refs/heads/"+result.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("ベースブランチのリファレンス取得に失敗: %w", err)
+	}
+
+	// ベースブランチと内容が同じファイルは空のコミットになるため除外する
+	files, unchanged, err := changedFiles(ctx, client, owner, repo, baseRef.GetObject().GetSHA(), req.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	// ローカルで削除されたファイルを同期する場合は、削除対象をベースブランチから求める
+	deletions := req.Deletions
+	if req.SyncDir != "" {
+		stale, err := staleFiles(ctx, client, owner, repo, baseRef.GetObject().GetSHA(), req.SyncDir, req.SyncExclude, req.Files)
+		if err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, stale...)
+	}
+
+	if len(files) == 0 && len(deletions) == 0 {
+		skipUnchanged(unchanged, result)
+		result.NoChanges = true
+		if len(req.Files) == 0 {
+			log.Printf("アップロードするファイルがないため、ブランチとプルリクエストは作成しません")
+			return result, nil
+		}
+		log.Printf("ベースリポジトリと内容が同じため、変更はありません。ブランチとプルリクエストは作成しません")
+		// 以前の実行で開いたプルリクエストには、ベースブランチと異なる古い内容が残っている
+		// 対象外にした入力がある場合は、そのファイルの変更がプルリクエストに残っている可能性があるため閉じない
+		if !req.Partial {
+			// フォーク経由の場合、作業用ブランチはフォークにある
+			headOwner, _, _, err := headRepository(ctx, client, owner, repo, repository, result)
+			if err != nil {
+				result.warn("作業用ブランチ '%s' のプルリクエストの確認に失敗: %v", req.Branch, err)
+				return result, nil
+			}
+			closeObsoletePullRequests(ctx, client, owner, repo, headOwner, req.Branch, result)
+		}
+		return result, nil
+	}
+
+	headOwner, headRepo, forked, err := headRepository(ctx, client, owner, repo, repository, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Forked = forked
+	result.HeadRepo = headOwner + "/" + headRepo
+
+	// 新しいブランチを作成
+	newRef := &github.Reference{
+		Ref:    github.String("refs/heads/" + req.Branch),
+		Object: baseRef.Object,
+	}
+
+	_, _, err = client.Git.CreateRef(ctx, headOwner, headRepo, newRef)
+	if err != nil {
+		if !strings.Contains(err.Error(), "Reference already exists") {
+			return nil, fmt.Errorf("ブランチの作成に失敗: %w", err)
+		}
+		log.Printf("ブランチ '%s' は既に存在します。既存のブランチに追加します", req.Branch)
+
+		result.BranchReset, err = refreshBranch(ctx, client, headOwner, headRepo, req.Branch, baseRef.GetObject().GetSHA())
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Printf("ブランチ '%s' を作成しました", req.Branch)
+		result.BranchCreated = true
+	}
+
+	// 既存のブランチをそのまま使う場合、ベースブランチと同じ内容に戻したファイルもブランチには古い内容が残っている
+	// 可能性があるため、コミットの対象に戻してブランチの内容と比較する
+	if result.BranchCreated || result.BranchReset {
+		skipUnchanged(unchanged, result)
+	} else {
+		files = append(files, unchanged...)
+	}
+
+	// 途中で失敗・中断した場合はブランチの状態を報告し、今回作成したブランチは削除する
+	defer func() {
+		if err == nil {
+			return
+		}
+		cleanupBranch(ctx, client, headOwner, headRepo, req.Branch, result.BranchCreated, append(result.Committed, result.Deleted...))
+	}()
+
+	// 各ファイルをコミット
+	for _, file := range files {
+		source := file.Source
+		if source == "" {
+			source = file.Path
+		}
+
+		// 既存ファイルの情報を取得（SHA取得のため）
+		var existingSHA string
+		fileContent, _, _, getErr := client.Repositories.GetContents(
+			ctx,
+			headOwner,
+			headRepo,
+			file.Path,
+			&github.RepositoryContentGetOptions{Ref: req.Branch},
+		)
+		if getErr == nil && fileContent != nil {
+			existingSHA = fileContent.GetSHA()
+		}
+
+		// 既存のブランチに同じ内容がコミット済みであれば再コミットしない
+		if existingSHA == gitutil.BlobSHA(file.Content) {
+			log.Printf("ファイル '%s' はブランチ '%s' の内容と同じため、スキップします", source, req.Branch)
+			result.Unchanged = append(result.Unchanged, file.Path)
+			continue
+		}
+
+		// ファイルをアップロード（更新または作成）
+		opts := &github.RepositoryContentFileOptions{
+			Message: github.String(req.Message),
+			Content: file.Content,
+			Branch:  github.String(req.Branch),
+		}
+
+		if existingSHA != "" {
+			opts.SHA = github.String(existingSHA)
+		}
+
+		if req.Verbose {
+			log.Printf("ファイル '%s' をパス '%s' にアップロード中...", source, file.Path)
+		}
+
+		_, _, err = client.Repositories.CreateFile(ctx, headOwner, headRepo, file.Path, opts)
+		if err != nil {
+			return nil, fmt.Errorf("ファイル '%s' のアップロードに失敗: %w", file.Path, err)
+		}
+
+		log.Printf("ファイル '%s' をアップロードしました: %s", source, file.Path)
+		result.Committed = append(result.Committed, file.Path)
+	}
+
+	// 指定されたファイルとローカルに存在しないファイルを削除
+	for _, filePath := range deletions {
+		fileContent, _, _, getErr := client.Repositories.GetContents(ctx, headOwner, headRepo, filePath,
+			&github.RepositoryContentGetOptions{Ref: req.Branch})
+		if ghclient.IsNotFound(getErr) || (getErr == nil && fileContent == nil) {
+			// 既存のブランチで削除済み
+			result.Deleted = append(result.Deleted, filePath)
+			continue
+		}
+		if getErr != nil {
+			return nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", filePath, getErr)
+		}
+
+		_, _, err = client.Repositories.DeleteFile(ctx, headOwner, headRepo, filePath, &github.RepositoryContentFileOptions{
+			Message: github.String(req.Message),
+			SHA:     github.String(fileContent.GetSHA()),
+			Branch:  github.String(req.Branch),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ファイル '%s' の削除に失敗: %w", filePath, err)
+		}
+
+		log.Printf("ファイル '%s' を削除しました", filePath)
+		result.Deleted = append(result.Deleted, filePath)
+	}
+
+	body := req.Body
+	if len(result.Deleted) > 0 {
+		body += "\n\n削除されたファイル:\n- " + strings.Join(result.Deleted, "\n- ")
+	}
+
+	// プルリクエストを作成
+	pr := &github.NewPullRequest{
+		Title:               github.String(req.Title),
+		Head:                github.String(headOwner + ":" + req.Branch),
+		Base:                github.String(result.BaseBranch),
+		Body:                github.String(body),
+		MaintainerCanModify: github.Bool(true),
+		Draft:               github.Bool(req.PullRequest.Draft),
+	}
+
+	pullRequest, _, err := client.PullRequests.Create(ctx, owner, repo, pr)
+	if err != nil {
+		// PR作成エラーチェック - 既に同じブランチでPRが存在する可能性がある
+		if strings.Contains(err.Error(), "pull request already exists") {
+			result.warn("このブランチからのPRは既に存在します")
+
+			// 既存PRを探す
+			prs, _, listErr := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+				Head:  headOwner + ":" + req.Branch,
+				Base:  result.BaseBranch,
+				State: "open",
+			})
+
+			if listErr == nil && len(prs) > 0 {
+				log.Printf("既存のPR #%d にコンテンツが追加されました: %s", prs[0].GetNumber(), prs[0].GetHTMLURL())
+				if req.PullRequest.Draft && !prs[0].GetDraft() {
+					result.warn("既存のPR #%d はドラフトに変更できないため、そのままにします", prs[0].GetNumber())
+				}
+				applyPullRequestOptions(ctx, client, owner, repo, prs[0], req.PullRequest, result)
+				result.PRNumber = prs[0].GetNumber()
+				result.PRURL = prs[0].GetHTMLURL()
+				result.PRExisted = true
+				return result, nil
+			}
+
+			return nil, fmt.Errorf("PR作成に失敗し、既存のPRも特定できません: %w", err)
+		}
+		return nil, fmt.Errorf("プルリクエストの作成に失敗: %w", err)
+	}
+
+	log.Printf("プルリクエスト #%d を作成しました: %s", pullRequest.GetNumber(), pullRequest.GetHTMLURL())
+	applyPullRequestOptions(ctx, client, owner, repo, pullRequest, req.PullRequest, result)
+	result.PRNumber = pullRequest.GetNumber()
+	result.PRURL = pullRequest.GetHTMLURL()
+	return result, nil
+}
+
+// refreshBranch は既存の作業用ブランチがベースブランチより遅れている場合、
+// プルリクエストをマージ可能に保つためベースブランチの最新コミットに作り直す
+// ファイルはこの後すべて再コミットされるため、ブランチ上の以前のコミットは破棄してよい
+func refreshBranch(ctx context.Context, client *github.Client, owner, repo, branch, baseSHA string) (bool, error) {
+	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, baseSHA, branch, nil)
+	if err != nil {
+		return false, fmt.Errorf("ブランチ '%s' とベースブランチの比較に失敗: %w", branch, err)
+	}
+	if comparison.GetBehindBy() == 0 {
+		return false, nil
+	}
+
+	_, _, err = client.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(baseSHA)},
+	}, true)
+	if err != nil {
+		return false, fmt.Errorf("ブランチ '%s' の更新に失敗: %w", branch, err)
+	}
+
+	log.Printf("ブランチ '%s' がベースブランチより %d コミット遅れていたため、ベースブランチの最新から作り直しました", branch, comparison.GetBehindBy())
+	return true, nil
+}
+
+// changedFiles はファイルをベースブランチのコミットと内容が異なるものと同じものに分ける
+// 内容の比較にはローカルで計算した blob の SHA を使うため、ファイル本体はダウンロードしない
+func changedFiles(ctx context.Context, client *github.Client, owner, repo, baseSHA string, files []File) (changed, unchanged []File, err error) {
+	for _, file := range files {
+		current, _, _, err := client.Repositories.GetContents(ctx, owner, repo, file.Path,
+			&github.RepositoryContentGetOptions{Ref: baseSHA})
+		if err != nil && !ghclient.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("ファイル '%s' の取得に失敗: %w", file.Path, err)
+		}
+
+		if current != nil && current.GetSHA() == gitutil.BlobSHA(file.Content) {
+			unchanged = append(unchanged, file)
+			continue
+		}
+		changed = append(changed, file)
+	}
+	return changed, unchanged, nil
+}
+
+// skipUnchanged はベースブランチと内容が同じためコミットしないファイルを記録する
+func skipUnchanged(files []File, result *Result) {
+	for _, file := range files {
+		source := file.Source
+		if source == "" {
+			source = file.Path
+		}
+		log.Printf("ファイル '%s' はベースリポジトリの内容と同じため、スキップします", source)
+		result.Unchanged = append(result.Unchanged, file.Path)
+	}
+}
+
+// staleFiles はベースブランチのコミットで dir 配下にあり、files に含まれないファイルを返す
+// exclude のディレクトリ配下のファイルは返さない
+func staleFiles(ctx context.Context, client *github.Client, owner, repo, baseSHA, dir string, exclude []string, files []File) ([]string, error) {
+	tree, _, err := client.Git.GetTree(ctx, owner, repo, baseSHA, true)
+	if err != nil {
+		return nil, fmt.Errorf("ベースブランチのファイル一覧の取得に失敗: %w", err)
+	}
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("ベースリポジトリのファイル数が多すぎるため、削除対象を特定できません")
+	}
+
+	keep := make(map[string]bool, len(files))
+	for _, file := range files {
+		keep[file.Path] = true
+	}
+
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	var stale []string
+	for _, entry := range tree.Entries {
+		if entry.GetType() != "blob" || !strings.HasPrefix(entry.GetPath(), prefix) || keep[entry.GetPath()] {
+			continue
+		}
+		if excluded(entry.GetPath(), exclude) {
+			continue
+		}
+		stale = append(stale, entry.GetPath())
+	}
+	return stale, nil
+}
+
+// headRepository は作業用ブランチを作成するリポジトリを返す
+// ベースリポジトリにプッシュできない場合は、フォークにブランチを作成してクロスリポジトリのPRにする
+// （権限情報が返されない場合は直接プッシュを試みる）
+func headRepository(ctx context.Context, client *github.Client, owner, repo string, repository *github.Repository, result *Result) (string, string, bool, error) {
+	if repository.Permissions == nil || repository.Permissions["push"] {
+		return owner, repo, false, nil
+	}
+	log.Printf("ベースリポジトリ %s/%s へのプッシュ権限がないため、フォーク経由でプルリクエストを作成します", owner, repo)
+	forkOwner, forkRepo, err := forkRepository(ctx, client, owner, repo, result.BaseBranch, result)
+	if err != nil {
+		return "", "", false, err
+	}
+	return forkOwner, forkRepo, true, nil
+}
+
+// excluded は p が dirs のいずれかのディレクトリ配下にあるかを返す
+func excluded(p string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupBranch は処理が途中で終了した場合にブランチの状態を報告し、
+// 今回作成したブランチであれば削除する
+func cleanupBranch(ctx context.Context, client *github.Client, owner, repo, branchName string, created bool, committed []string) {
+	if !created {
+		if len(committed) > 0 {
+			log.Printf("警告: 既存のブランチ '%s' には次のファイルがコミット済みです: %s", branchName, strings.Join(committed, ", "))
+		}
+		return
+	}
+
+	// 元のコンテキストはキャンセル済みの可能性があるため、後片付け用に短いタイムアウトを設定
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	if _, err := client.Git.DeleteRef(cleanupCtx, owner, repo, "refs/heads/"+branchName); err != nil {
+		log.Printf("警告: 作成したブランチ '%s' の削除に失敗しました（%d 件のファイルがコミット済み）: %v", branchName, len(committed), err)
+		return
+	}
+	log.Printf("処理が完了しなかったため、作成したブランチ '%s' を削除しました", branchName)
+}
@@ -0,0 +1,551 @@
+package publish
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+func TestCleanupBranch(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = append(deleted, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+
+	// キャンセル済みのコンテキストでも後片付けが行われることを確認
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 既存ブランチは削除しない
+	cleanupBranch(ctx, client, "testowner", "testrepo", "existing", false, []string{"a.md"})
+	if len(deleted) != 0 {
+		t.Errorf("既存ブランチが削除されました: %v", deleted)
+	}
+
+	// 今回作成したブランチは削除する
+	cleanupBranch(ctx, client, "testowner", "testrepo", "created", true, nil)
+	if len(deleted) != 1 || deleted[0] != "/repos/testowner/testrepo/git/refs/heads/created" {
+		t.Errorf("作成したブランチが削除されていません: %v", deleted)
+	}
+}
+
+// recordingServer はリクエストを記録し、ルーティング表に従ってレスポンスを返すモックサーバー
+type recordingServer struct {
+	mu       sync.Mutex
+	routes   map[string]func(w http.ResponseWriter, body string)
+	requests map[string]string // "METHOD path" -> リクエストボディ
+}
+
+func newRecordingServer(t *testing.T) (*recordingServer, *github.Client) {
+	t.Helper()
+	rs := &recordingServer{
+		routes:   map[string]func(w http.ResponseWriter, body string){},
+		requests: map[string]string{},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		key := r.Method + " " + r.URL.Path
+
+		rs.mu.Lock()
+		rs.requests[key] = string(body)
+		// "METHOD path?ref=..." で登録したルートはその ref の取得だけに使う
+		route, ok := rs.routes[key+"?ref="+r.URL.Query().Get("ref")]
+		if !ok {
+			route, ok = rs.routes[key]
+		}
+		rs.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		route(w, string(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+	return rs, client
+}
+
+func (rs *recordingServer) handle(key string, status int, body string) {
+	rs.routes[key] = func(w http.ResponseWriter, _ string) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// handleRepository はブランチ作成までに必要な共通のレスポンスを登録する
+func (rs *recordingServer) handleRepository() {
+	rs.handle("GET /repos/org/rules", http.StatusOK, `{"default_branch": "main"}`)
+	rs.handle("GET /repos/org/rules/git/ref/heads/main", http.StatusOK, `{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`)
+	rs.handle("POST /repos/org/rules/git/refs", http.StatusCreated, `{"ref": "refs/heads/topic"}`)
+	rs.handle("PUT /repos/org/rules/contents/app/rules.md", http.StatusCreated, `{"content": {}}`)
+	rs.handle("POST /repos/org/rules/issues/7/labels", http.StatusOK, `[]`)
+	rs.handle("POST /repos/org/rules/issues/7/assignees", http.StatusCreated, `{}`)
+	rs.handle("PATCH /repos/org/rules/issues/7", http.StatusOK, `{}`)
+	rs.handle("GET /repos/org/rules/milestones", http.StatusOK, `[{"number": 3, "title": "v1.0"}]`)
+	rs.handle("POST /repos/org/rules/pulls/7/requested_reviewers", http.StatusCreated, `{}`)
+}
+
+func testRequest() *Request {
+	return &Request{
+		Owner:   "org",
+		Repo:    "rules",
+		Branch:  "topic",
+		Files:   []File{{Path: "app/rules.md", Content: []byte("rules")}},
+		Message: "Update rules",
+		Title:   "[app] Update rules",
+		Body:    "body",
+		PullRequest: config.PullRequestConfig{
+			Labels:        []string{"rules/general"},
+			Reviewers:     []string{"alice", "me"},
+			TeamReviewers: []string{"platform"},
+			Assignees:     []string{"bob"},
+			Draft:         true,
+			Milestone:     "v1.0",
+		},
+	}
+}
+
+func TestRunAppliesPullRequestOptions(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated,
+		`{"number": 7, "html_url": "https://github.com/org/rules/pull/7", "user": {"login": "me"}}`)
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("PRの作成に失敗: %v", err)
+	}
+	if result.PRNumber != 7 || result.PRExisted || !result.BranchCreated {
+		t.Errorf("結果が一致しません: %+v", result)
+	}
+
+	assertOptionsApplied(t, rs)
+	if !strings.Contains(rs.requests["POST /repos/org/rules/pulls"], `"draft":true`) {
+		t.Errorf("ドラフトとして作成されていません: %s", rs.requests["POST /repos/org/rules/pulls"])
+	}
+}
+
+func TestRunAppliesPullRequestOptionsToExistingPR(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("POST /repos/org/rules/pulls", http.StatusUnprocessableEntity,
+		`{"message": "Validation Failed", "errors": [{"message": "A pull request already exists for org:topic."}]}`)
+	rs.handle("GET /repos/org/rules/pulls", http.StatusOK,
+		`[{"number": 7, "html_url": "https://github.com/org/rules/pull/7", "user": {"login": "me"}}]`)
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("既存PRへの追加に失敗: %v", err)
+	}
+	if result.PRNumber != 7 || !result.PRExisted {
+		t.Errorf("結果が一致しません: %+v", result)
+	}
+
+	assertOptionsApplied(t, rs)
+}
+
+func assertOptionsApplied(t *testing.T, rs *recordingServer) {
+	t.Helper()
+
+	expected := map[string]string{
+		"POST /repos/org/rules/issues/7/labels":             `["rules/general"]`,
+		"POST /repos/org/rules/issues/7/assignees":          `"assignees":["bob"]`,
+		"PATCH /repos/org/rules/issues/7":                   `"milestone":3`,
+		"POST /repos/org/rules/pulls/7/requested_reviewers": `"reviewers":["alice"],"team_reviewers":["platform"]`,
+	}
+	for key, want := range expected {
+		body, ok := rs.requests[key]
+		if !ok {
+			t.Errorf("%s が呼び出されていません", key)
+			continue
+		}
+		if !strings.Contains(body, want) {
+			t.Errorf("%s: ボディに %s が含まれていません: %s", key, want, body)
+		}
+	}
+}
+
+func TestRunSkipsUnchangedFiles(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules/contents/app/rules.md", http.StatusOK,
+		`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("rules"))+`"}`)
+	rs.handle("GET /repos/org/rules/pulls", http.StatusOK, `[]`)
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if !result.NoChanges || len(result.Unchanged) != 1 || result.PRClosed || len(result.Warnings) != 0 {
+		t.Errorf("変更なしと判定されていません: %+v", result)
+	}
+	for _, key := range []string{"POST /repos/org/rules/git/refs", "PUT /repos/org/rules/contents/app/rules.md", "POST /repos/org/rules/pulls"} {
+		if _, ok := rs.requests[key]; ok {
+			t.Errorf("変更がないのに %s が呼び出されました", key)
+		}
+	}
+}
+
+func TestRunSyncDeletions(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules/git/trees/basesha", http.StatusOK, `{"sha": "basesha", "tree": [
+		{"path": "app", "type": "tree"},
+		{"path": "app/rules.md", "type": "blob"},
+		{"path": "app/old.md", "type": "blob"},
+		{"path": "application/rules.md", "type": "blob"},
+		{"path": "general/rules.md", "type": "blob"}
+	]}`)
+	rs.handle("GET /repos/org/rules/contents/app/old.md", http.StatusOK, `{"type": "file", "sha": "oldsha"}`)
+	rs.handle("DELETE /repos/org/rules/contents/app/old.md", http.StatusOK, `{"content": null}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated, `{"number": 7, "user": {"login": "me"}}`)
+
+	req := testRequest()
+	req.SyncDir = "app"
+	result, err := Run(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+
+	if len(result.Deleted) != 1 || result.Deleted[0] != "app/old.md" {
+		t.Errorf("削除されたファイルが一致しません: %v", result.Deleted)
+	}
+	if body := rs.requests["DELETE /repos/org/rules/contents/app/old.md"]; !strings.Contains(body, `"sha":"oldsha"`) {
+		t.Errorf("削除リクエストにSHAが含まれていません: %s", body)
+	}
+	for key := range rs.requests {
+		if strings.HasPrefix(key, "DELETE ") && key != "DELETE /repos/org/rules/contents/app/old.md" {
+			t.Errorf("対象外のファイルが削除されました: %s", key)
+		}
+	}
+	if body := rs.requests["POST /repos/org/rules/pulls"]; !strings.Contains(body, "app/old.md") {
+		t.Errorf("PRの本文に削除されたファイルが含まれていません: %s", body)
+	}
+}
+
+func TestRunSyncDeletionsExcludesNestedProjects(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules/git/trees/basesha", http.StatusOK, `{"sha": "basesha", "tree": [
+		{"path": "app/rules.md", "type": "blob"},
+		{"path": "app/old.md", "type": "blob"},
+		{"path": "app/services/billing/CLAUDE.md", "type": "blob"},
+		{"path": "app/services/billing-old/CLAUDE.md", "type": "blob"}
+	]}`)
+	rs.handle("GET /repos/org/rules/contents/app/old.md", http.StatusOK, `{"type": "file", "sha": "oldsha"}`)
+	rs.handle("DELETE /repos/org/rules/contents/app/old.md", http.StatusOK, `{"content": null}`)
+	rs.handle("GET /repos/org/rules/contents/app/services/billing-old/CLAUDE.md", http.StatusOK, `{"type": "file", "sha": "billingsha"}`)
+	rs.handle("DELETE /repos/org/rules/contents/app/services/billing-old/CLAUDE.md", http.StatusOK, `{"content": null}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated, `{"number": 7, "user": {"login": "me"}}`)
+
+	// ルートのプロジェクトの中にあるサブプロジェクトのディレクトリは削除しない
+	req := testRequest()
+	req.SyncDir = "app"
+	req.SyncExclude = []string{"app/services/billing"}
+	result, err := Run(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+
+	expected := []string{"app/old.md", "app/services/billing-old/CLAUDE.md"}
+	if !reflect.DeepEqual(result.Deleted, expected) {
+		t.Errorf("削除されたファイル: 期待値 %v, 実際の値 %v", expected, result.Deleted)
+	}
+	if _, ok := rs.requests["DELETE /repos/org/rules/contents/app/services/billing/CLAUDE.md"]; ok {
+		t.Errorf("サブプロジェクトのファイルが削除されました")
+	}
+}
+
+func TestRunForksWithoutPushPermission(t *testing.T) {
+	forkPollInterval = time.Millisecond
+	t.Cleanup(func() { forkPollInterval = 2 * time.Second })
+
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules", http.StatusOK, `{"default_branch": "main", "permissions": {"pull": true, "push": false}}`)
+	rs.handle("POST /repos/org/rules/forks", http.StatusAccepted, `{"name": "rules", "owner": {"login": "me"}}`)
+	rs.handle("POST /repos/me/rules/merge-upstream", http.StatusOK, `{"merge_type": "fast-forward"}`)
+	rs.handle("POST /repos/me/rules/git/refs", http.StatusCreated, `{"ref": "refs/heads/topic"}`)
+	rs.handle("PUT /repos/me/rules/contents/app/rules.md", http.StatusCreated, `{"content": {}}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated, `{"number": 7, "user": {"login": "me"}}`)
+
+	// フォーク直後はブランチがまだ参照できない
+	polls := 0
+	rs.routes["GET /repos/me/rules/git/ref/heads/main"] = func(w http.ResponseWriter, _ string) {
+		polls++
+		if polls < 2 {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`))
+	}
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("フォーク経由のPR作成に失敗: %v", err)
+	}
+	if !result.Forked || result.HeadRepo != "me/rules" {
+		t.Errorf("フォーク経由になっていません: %+v", result)
+	}
+
+	for _, key := range []string{"POST /repos/me/rules/merge-upstream", "POST /repos/me/rules/git/refs", "PUT /repos/me/rules/contents/app/rules.md"} {
+		if _, ok := rs.requests[key]; !ok {
+			t.Errorf("%s が呼び出されていません", key)
+		}
+	}
+	if _, ok := rs.requests["POST /repos/org/rules/git/refs"]; ok {
+		t.Errorf("ベースリポジトリにブランチが作成されました")
+	}
+	if body := rs.requests["POST /repos/org/rules/pulls"]; !strings.Contains(body, `"head":"me:topic"`) {
+		t.Errorf("フォークのブランチからのPRになっていません: %s", body)
+	}
+}
+
+func TestRunResetsStaleBranch(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("POST /repos/org/rules/git/refs", http.StatusUnprocessableEntity, `{"message": "Reference already exists"}`)
+	rs.handle("GET /repos/org/rules/compare/basesha...topic", http.StatusOK, `{"ahead_by": 1, "behind_by": 2}`)
+	rs.handle("PATCH /repos/org/rules/git/refs/heads/topic", http.StatusOK, `{"ref": "refs/heads/topic"}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated, `{"number": 7, "user": {"login": "me"}}`)
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if result.BranchCreated || !result.BranchReset {
+		t.Errorf("既存のブランチが作り直されていません: %+v", result)
+	}
+	body := rs.requests["PATCH /repos/org/rules/git/refs/heads/topic"]
+	if !strings.Contains(body, `"sha":"basesha"`) || !strings.Contains(body, `"force":true`) {
+		t.Errorf("ブランチがベースブランチの最新に更新されていません: %s", body)
+	}
+	if _, ok := rs.requests["PUT /repos/org/rules/contents/app/rules.md"]; !ok {
+		t.Errorf("作り直したブランチにファイルがコミットされていません")
+	}
+}
+
+func TestRunKeepsUpToDateBranch(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("POST /repos/org/rules/git/refs", http.StatusUnprocessableEntity, `{"message": "Reference already exists"}`)
+	rs.handle("GET /repos/org/rules/compare/basesha...topic", http.StatusOK, `{"ahead_by": 1, "behind_by": 0}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusCreated, `{"number": 7, "user": {"login": "me"}}`)
+
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if result.BranchReset {
+		t.Errorf("最新のブランチが作り直されました: %+v", result)
+	}
+	if _, ok := rs.requests["PATCH /repos/org/rules/git/refs/heads/topic"]; ok {
+		t.Errorf("最新のブランチが更新されました")
+	}
+}
+
+func TestRunRevertsStaleFileOnExistingBranch(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("POST /repos/org/rules/git/refs", http.StatusUnprocessableEntity, `{"message": "Reference already exists"}`)
+	rs.handle("GET /repos/org/rules/compare/basesha...topic", http.StatusOK, `{"ahead_by": 1, "behind_by": 0}`)
+	rs.handle("POST /repos/org/rules/pulls", http.StatusUnprocessableEntity,
+		`{"message": "Validation Failed", "errors": [{"message": "A pull request already exists for org:topic."}]}`)
+	rs.handle("GET /repos/org/rules/pulls", http.StatusOK, `[{"number": 7, "user": {"login": "me"}}]`)
+
+	// ローカルの rules.md はベースブランチと同じ内容に戻ったが、作業用ブランチには古い変更が残っている
+	// new.md はベースブランチと異なるため、既存のプルリクエストは開いたままにする
+	rs.handle("GET /repos/org/rules/contents/app/rules.md?ref=basesha", http.StatusOK,
+		`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("rules"))+`"}`)
+	rs.handle("GET /repos/org/rules/contents/app/rules.md?ref=topic", http.StatusOK,
+		`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("stale"))+`"}`)
+	rs.handle("PUT /repos/org/rules/contents/app/new.md", http.StatusCreated, `{"content": {}}`)
+
+	req := testRequest()
+	req.PullRequest = config.PullRequestConfig{}
+	req.Files = append(req.Files, File{Path: "app/new.md", Content: []byte("new")})
+	result, err := Run(context.Background(), client, req)
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+
+	body, ok := rs.requests["PUT /repos/org/rules/contents/app/rules.md"]
+	if !ok || !strings.Contains(body, `"sha":"`+gitutil.BlobSHA([]byte("stale"))+`"`) {
+		t.Errorf("作業用ブランチの古い内容が戻されていません: %q", body)
+	}
+	if len(result.Committed) != 2 || len(result.Unchanged) != 0 {
+		t.Errorf("コミットしたファイルが期待と異なります: %+v", result)
+	}
+}
+
+func TestRunClosesObsoletePullRequest(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules/contents/app/rules.md", http.StatusOK,
+		`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("rules"))+`"}`)
+	rs.handle("GET /repos/org/rules/pulls", http.StatusOK,
+		`[{"number": 7, "html_url": "https://github.com/org/rules/pull/7", "head": {"ref": "topic", "repo": {"name": "rules", "owner": {"login": "org"}}}}]`)
+	rs.handle("POST /repos/org/rules/issues/7/comments", http.StatusCreated, `{}`)
+	rs.handle("PATCH /repos/org/rules/pulls/7", http.StatusOK, `{"number": 7, "state": "closed"}`)
+	rs.handle("DELETE /repos/org/rules/git/refs/heads/topic", http.StatusNoContent, ``)
+
+	// ローカルのすべてのファイルがベースブランチと同じになった
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if !result.NoChanges || !result.PRClosed || result.PRNumber != 7 || len(result.Warnings) != 0 {
+		t.Errorf("古い内容のプルリクエストが閉じられていません: %+v", result)
+	}
+	if body := rs.requests["PATCH /repos/org/rules/pulls/7"]; !strings.Contains(body, `"state":"closed"`) {
+		t.Errorf("プルリクエストを閉じるリクエストが送られていません: %q", body)
+	}
+	for _, key := range []string{"POST /repos/org/rules/issues/7/comments", "DELETE /repos/org/rules/git/refs/heads/topic"} {
+		if _, ok := rs.requests[key]; !ok {
+			t.Errorf("%s が呼び出されていません", key)
+		}
+	}
+	if _, ok := rs.requests["POST /repos/org/rules/git/refs"]; ok {
+		t.Errorf("変更がないのにブランチが作成されました")
+	}
+}
+
+func TestRunClosesObsoletePullRequestFromFork(t *testing.T) {
+	rs, client := newRecordingServer(t)
+	rs.handleRepository()
+	rs.handle("GET /repos/org/rules", http.StatusOK, `{"default_branch": "main", "permissions": {"pull": true, "push": false}}`)
+	rs.handle("GET /repos/org/rules/contents/app/rules.md", http.StatusOK,
+		`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("rules"))+`"}`)
+	rs.handle("POST /repos/org/rules/forks", http.StatusAccepted, `{"name": "rules", "owner": {"login": "me"}}`)
+	rs.handle("GET /repos/me/rules/git/ref/heads/main", http.StatusOK, `{"ref": "refs/heads/main", "object": {"sha": "basesha"}}`)
+	rs.handle("POST /repos/me/rules/merge-upstream", http.StatusOK, `{"merge_type": "none"}`)
+	// GitHub App のインストールトークンでは認証ユーザーを取得できない
+	rs.handle("GET /user", http.StatusForbidden, `{"message": "Resource not accessible by integration"}`)
+	rs.handle("GET /repos/org/rules/pulls", http.StatusOK,
+		`[{"number": 7, "html_url": "https://github.com/org/rules/pull/7", "head": {"ref": "topic", "repo": {"name": "rules", "owner": {"login": "me"}}}}]`)
+	rs.handle("POST /repos/org/rules/issues/7/comments", http.StatusCreated, `{}`)
+	rs.handle("PATCH /repos/org/rules/pulls/7", http.StatusOK, `{"number": 7, "state": "closed"}`)
+	rs.handle("DELETE /repos/me/rules/git/refs/heads/topic", http.StatusNoContent, ``)
+
+	// フォークの所有者はフォークの手順で求め、認証ユーザーは問い合わせない
+	result, err := Run(context.Background(), client, testRequest())
+	if err != nil {
+		t.Fatalf("実行に失敗: %v", err)
+	}
+	if !result.PRClosed || result.PRNumber != 7 || len(result.Warnings) != 0 {
+		t.Errorf("フォークからの古い内容のプルリクエストが閉じられていません: %+v", result)
+	}
+	if _, ok := rs.requests["GET /user"]; ok {
+		t.Errorf("認証ユーザーが問い合わせられました")
+	}
+	if _, ok := rs.requests["DELETE /repos/me/rules/git/refs/heads/topic"]; !ok {
+		t.Errorf("フォークの作業用ブランチが削除されていません")
+	}
+}
+
+func TestRunKeepsPullRequestWithoutComparedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *Request)
+	}{
+		// 対象のファイルがすべてローカルになく、送信するファイルがない
+		{"ファイルなし", func(req *Request) { req.Files = nil }},
+		// 一部の入力を対象外にしたため、送信したファイルだけではベースブランチと同じか判断できない
+		{"一部を対象外", func(req *Request) { req.Partial = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, client := newRecordingServer(t)
+			rs.handleRepository()
+			rs.handle("GET /repos/org/rules/contents/app/rules.md", http.StatusOK,
+				`{"type": "file", "sha": "`+gitutil.BlobSHA([]byte("rules"))+`"}`)
+			rs.handle("GET /repos/org/rules/pulls", http.StatusOK,
+				`[{"number": 7, "html_url": "https://github.com/org/rules/pull/7", "head": {"ref": "topic"}}]`)
+
+			req := testRequest()
+			tt.modify(req)
+			result, err := Run(context.Background(), client, req)
+			if err != nil {
+				t.Fatalf("実行に失敗: %v", err)
+			}
+			if !result.NoChanges || result.PRClosed {
+				t.Errorf("変更なしとして扱われていないか、プルリクエストが閉じられました: %+v", result)
+			}
+			for key := range rs.requests {
+				if strings.HasPrefix(key, "PATCH ") || strings.HasPrefix(key, "DELETE ") || strings.HasPrefix(key, "POST ") {
+					t.Errorf("既存のプルリクエストとブランチが変更されました: %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestResultRecord(t *testing.T) {
+	result := &Result{
+		Branch:    "update-rules",
+		PRNumber:  3,
+		PRURL:     "https://github.com/org/rules/pull/3",
+		Committed: []string{"app/AGENTS.md"},
+		Deleted:   []string{"app/old.md"},
+		Warnings:  []string{"draft"},
+	}
+	files := []File{
+		{Path: "app/AGENTS.md", Content: []byte("new"), Source: "AGENTS.md"},
+		{Path: "app/CLAUDE.md", Content: []byte("same"), Source: "CLAUDE.md"},
+	}
+
+	out := output.NewResult("")
+	result.Record(out, files)
+
+	want := []output.File{
+		{Path: "AGENTS.md", RemotePath: "app/AGENTS.md", SHA: gitutil.BlobSHA([]byte("new")), Status: output.StatusCommitted},
+		{Path: "CLAUDE.md", RemotePath: "app/CLAUDE.md", SHA: gitutil.BlobSHA([]byte("same")), Status: output.StatusUnchanged},
+		{RemotePath: "app/old.md", Status: output.StatusDeleted},
+	}
+	if len(out.Files) != len(want) {
+		t.Fatalf("ファイル数: 期待値 %d, 実際の値 %d (%+v)", len(want), len(out.Files), out.Files)
+	}
+	for i := range want {
+		if out.Files[i] != want[i] {
+			t.Errorf("%d 番目: 期待値 %+v, 実際の値 %+v", i, want[i], out.Files[i])
+		}
+	}
+	if out.Branch != "update-rules" || out.PullRequest == nil || out.PullRequest.Number != 3 || len(out.Warnings) != 1 {
+		t.Errorf("ブランチ、プルリクエスト、警告が期待と異なります: %+v", out)
+	}
+
+	// 変更がなかった場合はブランチを記録しない
+	out = output.NewResult("")
+	(&Result{Branch: "update-rules", NoChanges: true}).Record(out, nil)
+	if out.Branch != "" || out.PullRequest != nil {
+		t.Errorf("変更がないのにブランチまたはプルリクエストが記録されました: %+v", out)
+	}
+}
@@ -0,0 +1,126 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+// applyPullRequestOptions はラベル、アサイン、マイルストーン、レビュー依頼をプルリクエストに設定する
+// プルリクエスト自体は作成済みのため、設定に失敗しても警告にとどめる
+func applyPullRequestOptions(ctx context.Context, client *github.Client, owner, repo string, pr *github.PullRequest, opts config.PullRequestConfig, result *Result) {
+	number := pr.GetNumber()
+
+	if len(opts.Labels) > 0 {
+		if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, number, opts.Labels); err != nil {
+			result.warn("PR #%d へのラベルの設定に失敗: %v", number, err)
+		}
+	}
+
+	if len(opts.Assignees) > 0 {
+		if _, _, err := client.Issues.AddAssignees(ctx, owner, repo, number, opts.Assignees); err != nil {
+			result.warn("PR #%d へのアサインに失敗: %v", number, err)
+		}
+	}
+
+	if opts.Milestone != "" {
+		milestone, err := findMilestone(ctx, client, owner, repo, opts.Milestone)
+		if err == nil {
+			_, _, err = client.Issues.Edit(ctx, owner, repo, number, &github.IssueRequest{Milestone: github.Int(milestone)})
+		}
+		if err != nil {
+			result.warn("PR #%d へのマイルストーン '%s' の設定に失敗: %v", number, opts.Milestone, err)
+		}
+	}
+
+	// PRの作成者自身にはレビューを依頼できないため除外する
+	reviewers := make([]string, 0, len(opts.Reviewers))
+	for _, r := range opts.Reviewers {
+		if !strings.EqualFold(r, pr.GetUser().GetLogin()) {
+			reviewers = append(reviewers, r)
+		}
+	}
+	if len(reviewers) > 0 || len(opts.TeamReviewers) > 0 {
+		_, _, err := client.PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{
+			Reviewers:     reviewers,
+			TeamReviewers: opts.TeamReviewers,
+		})
+		if err != nil {
+			result.warn("PR #%d へのレビュー依頼に失敗: %v", number, err)
+		}
+	}
+}
+
+// findMilestone はマイルストーンのタイトルまたは番号から番号を求める
+func findMilestone(ctx context.Context, client *github.Client, owner, repo, milestone string) (int, error) {
+	if n, err := strconv.Atoi(milestone); err == nil {
+		return n, nil
+	}
+
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return 0, fmt.Errorf("マイルストーン一覧の取得に失敗: %w", err)
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == milestone {
+				return m.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return 0, fmt.Errorf("オープンなマイルストーン '%s' が見つかりません", milestone)
+}
+
+// closeObsoletePullRequests は作業用ブランチから開いたままのプルリクエストを閉じ、ブランチを削除する
+// ローカルの内容がベースブランチと同じになった後も古い内容のプルリクエストがマージされないようにする
+// headOwner は作業用ブランチのあるリポジトリ（フォーク経由の場合はフォーク）の所有者
+// 変更のアップロード自体は不要になっているため、失敗しても警告にとどめる
+func closeObsoletePullRequests(ctx context.Context, client *github.Client, owner, repo, headOwner, branch string, result *Result) {
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		Head:  headOwner + ":" + branch,
+		Base:  result.BaseBranch,
+		State: "open",
+	})
+	if err != nil {
+		result.warn("作業用ブランチ '%s' のプルリクエストの確認に失敗: %v", branch, err)
+		return
+	}
+
+	for _, pr := range prs {
+		number := pr.GetNumber()
+		comment := "ローカルのルールがベースブランチと同じ内容になったため、ruleforge がこのプルリクエストを閉じました。"
+		if _, _, err := client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.String(comment)}); err != nil {
+			result.warn("PR #%d へのコメントに失敗: %v", number, err)
+		}
+		if _, _, err := client.PullRequests.Edit(ctx, owner, repo, number, &github.PullRequest{State: github.String("closed")}); err != nil {
+			result.warn("古い内容の PR #%d を閉じられませんでした: %v", number, err)
+			continue
+		}
+		log.Printf("ベースリポジトリと内容が同じになったため、PR #%d を閉じました: %s", number, pr.GetHTMLURL())
+		result.PRNumber = number
+		result.PRURL = pr.GetHTMLURL()
+		result.PRExisted = true
+		result.PRClosed = true
+
+		headRepoOwner, headRepo := headOwner, repo
+		if r := pr.GetHead().GetRepo(); r != nil {
+			headRepoOwner, headRepo = r.GetOwner().GetLogin(), r.GetName()
+		}
+		if _, err := client.Git.DeleteRef(ctx, headRepoOwner, headRepo, "refs/heads/"+branch); err != nil {
+			result.warn("作業用ブランチ '%s' の削除に失敗: %v", branch, err)
+			continue
+		}
+		log.Printf("作業用ブランチ '%s' を削除しました", branch)
+	}
+}
@@ -0,0 +1,222 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+)
+
+// DriftOptions はドリフトレポートの実行オプション
+type DriftOptions struct {
+	// 指定した組織の全リポジトリを対象にする
+	Org string
+
+	// 指定したトピックを持つリポジトリに絞り込む（Org 未指定の場合はベースリポジトリの所有者のリポジトリから検索）
+	Topic string
+
+	// 出力形式（markdown, json, csv）
+	Format string
+
+	// 出力先（nilの場合は標準出力）
+	Out io.Writer
+}
+
+// RepoResult は1リポジトリ分のドリフト結果
+type RepoResult struct {
+	Repository string             `json:"repository"`
+	Status     string             `json:"status"`
+	Files      []drift.FileStatus `json:"files,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// DriftReport はドリフトレポート全体
+type DriftReport struct {
+	BaseRepo string       `json:"base-repo"`
+	BaseSHA  string       `json:"base-sha"`
+	Repos    []RepoResult `json:"repos"`
+}
+
+// Drift は利用側リポジトリのルールファイルがベースリポジトリから遅れていないかを調べて出力する
+func Drift(ctx context.Context, cfg *config.Config, opts DriftOptions) error {
+	if err := validateFormat(opts.Format); err != nil {
+		return err
+	}
+
+	baseOwner, baseRepo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+
+	report, err := buildDriftReport(ctx, client, baseOwner, baseRepo, cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	return writeDriftReport(out, opts.Format, report)
+}
+
+// buildDriftReport は対象リポジトリを並列に比較し、設定順（組織指定時は名前順）に結果をまとめる
+func buildDriftReport(ctx context.Context, client *github.Client, baseOwner, baseRepo string, cfg *config.Config, opts DriftOptions) (*DriftReport, error) {
+	// 比較中にベースリポジトリが更新されても結果が揃うよう、最新コミットに固定する
+	repository, _, err := client.Repositories.Get(ctx, baseOwner, baseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("ベースリポジトリ情報の取得に失敗: %w", err)
+	}
+	baseRef, _, err := client.Git.GetRef(ctx, baseOwner, baseRepo, "refs/heads/"+repository.GetDefaultBranch())
+	if err != nil {
+		return nil, fmt.Errorf("ベースブランチのリファレンス取得に失敗: %w", err)
+	}
+	baseSHA := baseRef.GetObject().GetSHA()
+
+	targets, err := listTargets(ctx, client, baseOwner+"/"+baseRepo, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("対象リポジトリがありません。設定ファイルの fleet.repos、--org または --topic で指定してください")
+	}
+
+	resolver := drift.NewResolver(&download.Source{Client: client, Owner: baseOwner, Repo: baseRepo, Ref: baseSHA}, cfg.Layout)
+
+	workers := cfg.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	results := make([]RepoResult, len(targets))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+
+			result := RepoResult{Repository: target}
+			var err error
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				result.Files, err = compare(ctx, client, resolver, target, cfg.Files)
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			if err != nil {
+				result.Status = drift.StateError
+				result.Error = err.Error()
+			} else {
+				result.Status = drift.Summarize(result.Files)
+			}
+			results[i] = result
+		}(i, target)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("ドリフトの確認を中断しました: %w", err)
+	}
+
+	for _, r := range results {
+		if r.Status == drift.StateError {
+			log.Printf("警告: %s を確認できませんでした: %s", r.Repository, r.Error)
+		}
+	}
+
+	return &DriftReport{
+		BaseRepo: baseOwner + "/" + baseRepo,
+		BaseSHA:  baseSHA,
+		Repos:    results,
+	}, nil
+}
+
+// compare は1リポジトリの対象ファイルを比較する
+func compare(ctx context.Context, client *github.Client, resolver *drift.Resolver, target string, files []string) ([]drift.FileStatus, error) {
+	owner, repo, err := ghclient.ParseRepoURL(target)
+	if err != nil {
+		return nil, err
+	}
+	return drift.CompareRepo(ctx, client, resolver, owner, repo, files)
+}
+
+// listTargets は比較対象のリポジトリを列挙する
+// --org / --topic の指定がなければ設定ファイルの fleet.repos を使う
+func listTargets(ctx context.Context, client *github.Client, baseFullName string, cfg *config.Config, opts DriftOptions) ([]string, error) {
+	if opts.Org == "" && opts.Topic == "" {
+		return cfg.Fleet.Repos, nil
+	}
+
+	var repos []*github.Repository
+	if opts.Org != "" {
+		listOpts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			page, resp, err := client.Repositories.ListByOrg(ctx, opts.Org, listOpts)
+			if err != nil {
+				return nil, fmt.Errorf("組織 '%s' のリポジトリ一覧の取得に失敗: %w", opts.Org, err)
+			}
+			repos = append(repos, page...)
+			if resp.NextPage == 0 {
+				break
+			}
+			listOpts.Page = resp.NextPage
+		}
+	} else {
+		// GitHub 全体の無関係なリポジトリを比較しないよう、ベースリポジトリの所有者（ユーザーまたは組織）に限定する
+		owner, _, _ := strings.Cut(baseFullName, "/")
+		query := fmt.Sprintf("topic:%s user:%s", opts.Topic, owner)
+		searchOpts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			result, resp, err := client.Search.Repositories(ctx, query, searchOpts)
+			if err != nil {
+				return nil, fmt.Errorf("トピック '%s' のリポジトリ検索に失敗: %w", opts.Topic, err)
+			}
+			repos = append(repos, result.Repositories...)
+			if resp.NextPage == 0 {
+				break
+			}
+			searchOpts.Page = resp.NextPage
+		}
+	}
+
+	var targets []string
+	for _, r := range repos {
+		// アーカイブ済みのリポジトリとベースリポジトリ自身は対象外
+		if r.GetArchived() || r.GetFullName() == baseFullName {
+			continue
+		}
+		if opts.Topic != "" && !hasTopic(r, opts.Topic) {
+			continue
+		}
+		targets = append(targets, r.GetFullName())
+	}
+	sort.Strings(targets)
+
+	return targets, nil
+}
+
+func hasTopic(r *github.Repository, topic string) bool {
+	for _, t := range r.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
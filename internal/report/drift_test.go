@@ -0,0 +1,68 @@
+package report
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+)
+
+func TestListTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// トピックだけの検索はベースリポジトリの所有者に限定する
+		if r.URL.Path == "/search/repositories" && r.URL.Query().Get("q") == "topic:ai-rules user:org" {
+			_, _ = w.Write([]byte(`{"total_count": 2, "items": [
+				{"full_name": "org/zeta", "topics": ["ai-rules"]},
+				{"full_name": "org/rules", "topics": ["ai-rules"]}
+			]}`))
+			return
+		}
+		if r.URL.Path != "/orgs/org/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"full_name": "org/zeta", "topics": ["ai-rules"]},
+			{"full_name": "org/rules", "topics": ["ai-rules"]},
+			{"full_name": "org/old", "archived": true, "topics": ["ai-rules"]},
+			{"full_name": "org/alpha", "topics": ["ai-rules", "go"]},
+			{"full_name": "org/other", "topics": ["go"]}
+		]`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	baseURL, _ := url.Parse(server.URL + "/")
+	client.BaseURL = baseURL
+
+	cfg := &config.Config{Fleet: config.FleetConfig{Repos: []string{"org/configured"}}}
+
+	testCases := []struct {
+		name     string
+		opts     DriftOptions
+		expected []string
+	}{
+		{"設定ファイルのリポジトリ", DriftOptions{}, []string{"org/configured"}},
+		{"組織の全リポジトリ", DriftOptions{Org: "org"}, []string{"org/alpha", "org/other", "org/zeta"}},
+		{"組織とトピック", DriftOptions{Org: "org", Topic: "ai-rules"}, []string{"org/alpha", "org/zeta"}},
+		{"トピックのみ", DriftOptions{Topic: "ai-rules"}, []string{"org/zeta"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targets, err := listTargets(context.Background(), client, "org/rules", cfg, tc.opts)
+			if err != nil {
+				t.Fatalf("対象リポジトリの取得に失敗: %v", err)
+			}
+			if !reflect.DeepEqual(targets, tc.expected) {
+				t.Errorf("期待値 %v, 実際の値 %v", tc.expected, targets)
+			}
+		})
+	}
+}
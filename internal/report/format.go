@@ -0,0 +1,125 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+)
+
+// 出力形式
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+)
+
+// validateFormat は出力形式が対応しているかを確認する
+func validateFormat(format string) error {
+	switch format {
+	case FormatMarkdown, FormatJSON, FormatCSV:
+		return nil
+	}
+	return fmt.Errorf("未対応の出力形式です: %s（markdown, json, csv のいずれかを指定してください）", format)
+}
+
+// writeDriftReport はドリフトレポートを指定された形式で出力する
+func writeDriftReport(w io.Writer, format string, report *DriftReport) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case FormatCSV:
+		return writeDriftCSV(w, report)
+	default:
+		return writeDriftMarkdown(w, report)
+	}
+}
+
+// writeDriftMarkdown はリポジトリごとの状態をMarkdownの表として出力する
+func writeDriftMarkdown(w io.Writer, report *DriftReport) error {
+	counts := map[string]int{}
+	for _, r := range report.Repos {
+		counts[r.Status]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# ルールのドリフトレポート\n\n")
+	fmt.Fprintf(&b, "ベースリポジトリ: `%s` (`%s`)\n\n", report.BaseRepo, shortSHA(report.BaseSHA))
+	fmt.Fprintf(&b, "最新: %d / ドリフト: %d / 未導入: %d / エラー: %d\n\n",
+		counts[drift.StateUpToDate], counts[drift.StateDrifted], counts[drift.StateMissing], counts[drift.StateError])
+
+	b.WriteString("| リポジトリ | 状態 | 差分のあるファイル | 存在しないファイル |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, r := range report.Repos {
+		status := r.Status
+		if r.Error != "" {
+			status = fmt.Sprintf("%s: %s", r.Status, escapeMarkdownCell(r.Error))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			r.Repository,
+			status,
+			codeList(filesWithState(r.Files, drift.StateDrifted)),
+			codeList(filesWithState(r.Files, drift.StateMissing)),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeDriftCSV はリポジトリごとの状態をCSVとして出力する
+func writeDriftCSV(w io.Writer, report *DriftReport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repository", "status", "drifted_files", "missing_files", "error"}); err != nil {
+		return err
+	}
+	for _, r := range report.Repos {
+		record := []string{
+			r.Repository,
+			r.Status,
+			strings.Join(filesWithState(r.Files, drift.StateDrifted), ";"),
+			strings.Join(filesWithState(r.Files, drift.StateMissing), ";"),
+			r.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func filesWithState(files []drift.FileStatus, state string) []string {
+	var paths []string
+	for _, f := range files {
+		if f.State == state {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+func codeList(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = "`" + p + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+)
+
+func testReport() *DriftReport {
+	return &DriftReport{
+		BaseRepo: "org/rules",
+		BaseSHA:  "0123456789abcdef",
+		Repos: []RepoResult{
+			{Repository: "org/a", Status: drift.StateUpToDate, Files: []drift.FileStatus{{Path: "rules.md", State: drift.StateUpToDate}}},
+			{Repository: "org/b", Status: drift.StateDrifted, Files: []drift.FileStatus{
+				{Path: "rules.md", State: drift.StateDrifted},
+				{Path: "AGENTS.md", State: drift.StateMissing},
+			}},
+			{Repository: "org/c", Status: drift.StateError, Error: "404 Not Found"},
+		},
+	}
+}
+
+func TestWriteDriftReport(t *testing.T) {
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDriftReport(&buf, FormatMarkdown, testReport()); err != nil {
+			t.Fatalf("出力に失敗: %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			"`org/rules` (`0123456`)",
+			"最新: 1 / ドリフト: 1 / 未導入: 0 / エラー: 1",
+			"| org/b | drifted | `rules.md` | `AGENTS.md` |",
+			"| org/c | error: 404 Not Found |  |  |",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("出力に %q が含まれていません:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDriftReport(&buf, FormatCSV, testReport()); err != nil {
+			t.Fatalf("出力に失敗: %v", err)
+		}
+		expected := "repository,status,drifted_files,missing_files,error\n" +
+			"org/a,up-to-date,,,\n" +
+			"org/b,drifted,rules.md,AGENTS.md,\n" +
+			"org/c,error,,,404 Not Found\n"
+		if buf.String() != expected {
+			t.Errorf("期待値:\n%s\n実際の値:\n%s", expected, buf.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDriftReport(&buf, FormatJSON, testReport()); err != nil {
+			t.Fatalf("出力に失敗: %v", err)
+		}
+		var decoded DriftReport
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("JSONの解析に失敗: %v", err)
+		}
+		if len(decoded.Repos) != 3 || decoded.Repos[1].Files[1].State != drift.StateMissing {
+			t.Errorf("JSONの内容が一致しません: %+v", decoded)
+		}
+	})
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatMarkdown, FormatJSON, FormatCSV} {
+		if err := validateFormat(format); err != nil {
+			t.Errorf("%s: 予期しないエラー: %v", format, err)
+		}
+	}
+	if err := validateFormat("xml"); err == nil {
+		t.Errorf("未対応の形式でエラーが期待されましたが、成功しました")
+	}
+}
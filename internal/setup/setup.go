@@ -0,0 +1,255 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/prompt"
+)
+
+// defaultFile はエージェントのルールファイルが見つからない場合に提案する対象ファイル
+const defaultFile = ".cursor/rules.md"
+
+// agentFiles は検出するエージェントのルールファイル（リポジトリのルートからの相対パス）
+var agentFiles = []string{
+	".cursor/rules.md",
+	"CLAUDE.md",
+	".github/copilot-instructions.md",
+	"AGENTS.md",
+	".windsurfrules",
+}
+
+// agentRuleDirs はルールファイルを配置するディレクトリ（配下の .md と .mdc を検出する）
+var agentRuleDirs = []string{
+	".cursor/rules",
+}
+
+// Options は init コマンドの実行オプション
+type Options struct {
+	// 出力する設定ファイルのパス
+	Output string
+
+	// ベースリポジトリ（空の場合は対話的に入力する）
+	BaseRepo string
+
+	// 対象ファイル（空の場合は検出したファイルから選択する）
+	Files []string
+
+	// 対話的な入力をせず、指定された値と検出した値で設定ファイルを生成する
+	Yes bool
+
+	// 既存の設定ファイルを上書きする
+	Force bool
+
+	// エージェントのルールファイルを探すディレクトリ（空の場合はカレントディレクトリ）
+	Dir string
+
+	// 対話的な入力と結果の出力先（nilの場合は標準入出力）
+	In  io.Reader
+	Out io.Writer
+}
+
+// Execute はエージェントのルールファイルを検出し、ベースリポジトリへのアクセスを確認してから設定ファイルを生成する
+// cfg はユーザー設定ファイルや環境変数の値（ベースリポジトリの候補と認証情報）として使う
+// 結果には生成した設定ファイルと、設定した対象ファイルを記録する
+func Execute(ctx context.Context, cfg *config.Config, opts Options) (*output.Result, error) {
+	res := output.NewResult("")
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return res, res.Fail(fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err))
+	}
+	return res, res.Fail(run(ctx, client, cfg, opts, res))
+}
+
+func run(ctx context.Context, client *github.Client, cfg *config.Config, opts Options, res *output.Result) error {
+	in, out := opts.In, opts.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	// 入力を終えてから既存のファイルに気付かないよう、最初に確認する
+	if _, err := os.Stat(opts.Output); err == nil && !opts.Force {
+		return fmt.Errorf("設定ファイル %s は既に存在します。上書きするには --force を指定してください", opts.Output)
+	}
+
+	p := prompt.New(in, out)
+
+	files := opts.Files
+	if len(files) == 0 {
+		detected, err := DetectAgentFiles(dir)
+		if err != nil {
+			return err
+		}
+		files, err = chooseFiles(p, out, detected, opts.Yes)
+		if err != nil {
+			return err
+		}
+	}
+
+	baseRepo, err := chooseBaseRepo(ctx, client, p, out, opts.BaseRepo, cfg.BaseRepo, opts.Yes)
+	if err != nil {
+		return err
+	}
+
+	if err := config.GenerateConfigFile(opts.Output, baseRepo, files, opts.Force); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "設定ファイル %s を生成しました（ベースリポジトリ: %s、対象ファイル: %s）\n", opts.Output, baseRepo, strings.Join(files, ", "))
+
+	res.Files = append(res.Files, output.File{Path: opts.Output, Status: output.StatusGenerated})
+	for _, f := range files {
+		res.Files = append(res.Files, output.File{Path: f, Status: output.StatusSelected})
+	}
+	return nil
+}
+
+// DetectAgentFiles はディレクトリにある既知のエージェントのルールファイルを探し、相対パス（/ 区切り）を返す
+func DetectAgentFiles(dir string) ([]string, error) {
+	var found []string
+	for _, file := range agentFiles {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file)))
+		if err == nil && info.Mode().IsRegular() {
+			found = append(found, file)
+		}
+	}
+
+	for _, ruleDir := range agentRuleDirs {
+		root := filepath.Join(dir, filepath.FromSlash(ruleDir))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if ext := filepath.Ext(p); ext != ".md" && ext != ".mdc" {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			found = append(found, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s の読み込みに失敗: %w", ruleDir, err)
+		}
+	}
+
+	return found, nil
+}
+
+// chooseFiles は検出したファイルから対象ファイルを選ぶ
+// 何も検出できなかった場合は入力を求める（--yes の場合はデフォルトの対象ファイルを使う）
+func chooseFiles(p *prompt.Prompter, out io.Writer, detected []string, yes bool) ([]string, error) {
+	if len(detected) == 0 {
+		if yes {
+			return []string{defaultFile}, nil
+		}
+		fmt.Fprintln(out, "エージェントのルールファイルが見つかりませんでした")
+		answer, err := p.Ask("対象ファイル（カンマ区切り）", defaultFile)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, f := range strings.Split(answer, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				files = append(files, f)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("対象ファイルが指定されていません")
+		}
+		return files, nil
+	}
+
+	if yes {
+		return detected, nil
+	}
+
+	fmt.Fprintln(out, "エージェントのルールファイルを検出しました:")
+	selected, err := p.Select("設定に含めるファイル（何も入力しなければすべて）", detected)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return detected, nil
+	}
+	files := make([]string, 0, len(selected))
+	for _, i := range selected {
+		files = append(files, detected[i])
+	}
+	return files, nil
+}
+
+// chooseBaseRepo はベースリポジトリを決め、アクセスできることを確認する
+// フラグで指定されていない場合は、ユーザー設定ファイルや環境変数の値をデフォルトとして入力を求める
+func chooseBaseRepo(ctx context.Context, client *github.Client, p *prompt.Prompter, out io.Writer, given, suggested string, yes bool) (string, error) {
+	if given != "" || yes {
+		baseRepo := given
+		if baseRepo == "" {
+			baseRepo = suggested
+		}
+		if baseRepo == "" {
+			return "", fmt.Errorf("ベースリポジトリが指定されていません。--base-repo で指定してください")
+		}
+		if err := checkAccess(ctx, client, baseRepo); err != nil {
+			return "", err
+		}
+		return baseRepo, nil
+	}
+
+	for {
+		baseRepo, err := p.Ask("ベースリポジトリ（owner/repo またはURL）", suggested)
+		if err != nil {
+			return "", err
+		}
+		if baseRepo == "" {
+			return "", fmt.Errorf("ベースリポジトリが指定されていません")
+		}
+
+		err = checkAccess(ctx, client, baseRepo)
+		if err == nil {
+			return baseRepo, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		// 入力が終端に達した場合に同じ値で繰り返さないよう、再入力ではデフォルト値を提示しない
+		fmt.Fprintf(out, "%v\n", err)
+		suggested = ""
+	}
+}
+
+// checkAccess はベースリポジトリを参照できることを確認する
+func checkAccess(ctx context.Context, client *github.Client, baseRepo string) error {
+	owner, repo, err := ghclient.ParseRepoURL(baseRepo)
+	if err != nil {
+		return err
+	}
+	if _, _, err := client.Repositories.Get(ctx, owner, repo); err != nil {
+		if ghclient.IsNotFound(err) {
+			return fmt.Errorf("ベースリポジトリ %s/%s が見つかりません（存在しないか、認証情報にアクセス権がありません）", owner, repo)
+		}
+		return fmt.Errorf("ベースリポジトリ %s/%s へのアクセスに失敗: %w", owner, repo, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// newTestClient は org/rules だけが存在するGitHub APIのモックに接続するクライアントを返す
+func newTestClient(t *testing.T) *github.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "GET" && r.URL.Path == "/repos/org/rules" {
+			_, _ = w.Write([]byte(`{"full_name": "org/rules", "default_branch": "main"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("URLの解析に失敗: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+// writeFiles はディレクトリにテスト用のファイルを作成する
+func writeFiles(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗: %v", err)
+		}
+		if err := os.WriteFile(full, []byte("# rules\n"), 0644); err != nil {
+			t.Fatalf("ファイルの作成に失敗: %v", err)
+		}
+	}
+}
+
+// loadGenerated は生成された設定ファイルを読み込む
+func loadGenerated(t *testing.T, path string) *config.Config {
+	t.Helper()
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("生成された設定ファイルの読み込みに失敗: %v", err)
+	}
+	return cfg
+}
+
+func TestDetectAgentFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"CLAUDE.md",
+		"AGENTS.md",
+		".github/copilot-instructions.md",
+		".cursor/rules/general.mdc",
+		".cursor/rules/go/testing.md",
+		".cursor/rules/notes.txt",
+		"README.md",
+	)
+
+	got, err := DetectAgentFiles(dir)
+	if err != nil {
+		t.Fatalf("検出に失敗: %v", err)
+	}
+	expected := []string{
+		"CLAUDE.md",
+		".github/copilot-instructions.md",
+		"AGENTS.md",
+		".cursor/rules/general.mdc",
+		".cursor/rules/go/testing.md",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("検出結果が一致しません: 期待値 %v, 実際の値 %v", expected, got)
+	}
+}
+
+func TestRunInteractive(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "CLAUDE.md", "AGENTS.md", ".windsurfrules")
+	configFile := filepath.Join(dir, ".ruleforge.yaml")
+
+	// 2つ目と3つ目のファイルを選び、存在しないリポジトリを入力した後に正しいリポジトリを入力する
+	input := "2,3\norg/missing\norg/rules\n"
+	var out bytes.Buffer
+	opts := Options{Output: configFile, Dir: dir, In: strings.NewReader(input), Out: &out}
+	res := output.NewResult("")
+	if err := run(context.Background(), newTestClient(t), &config.Config{}, opts, res); err != nil {
+		t.Fatalf("設定ファイルの生成に失敗: %v", err)
+	}
+
+	// 生成した設定ファイルと選んだ対象ファイルを結果に記録する
+	want := []output.File{
+		{Path: configFile, Status: output.StatusGenerated},
+		{Path: "AGENTS.md", Status: output.StatusSelected},
+		{Path: ".windsurfrules", Status: output.StatusSelected},
+	}
+	if !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("結果のファイル: 期待値 %+v, 実際の値 %+v", want, res.Files)
+	}
+
+	for _, want := range []string{"1) CLAUDE.md", "org/missing が見つかりません", "を生成しました"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません: %s", want, out.String())
+		}
+	}
+
+	cfg := loadGenerated(t, configFile)
+	if cfg.BaseRepo != "org/rules" {
+		t.Errorf("BaseRepo: 期待値 org/rules, 実際の値 %s", cfg.BaseRepo)
+	}
+	if expected := []string{"AGENTS.md", ".windsurfrules"}; !reflect.DeepEqual(cfg.Files, expected) {
+		t.Errorf("Files: 期待値 %v, 実際の値 %v", expected, cfg.Files)
+	}
+}
+
+func TestRunSuggestsConfiguredBaseRepo(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "CLAUDE.md")
+	configFile := filepath.Join(dir, ".ruleforge.yaml")
+
+	// 何も入力しなければ、すべての検出ファイルとユーザー設定のベースリポジトリを使う
+	var out bytes.Buffer
+	opts := Options{Output: configFile, Dir: dir, In: strings.NewReader("\n\n"), Out: &out}
+	if err := run(context.Background(), newTestClient(t), &config.Config{BaseRepo: "https://github.com/org/rules"}, opts, output.NewResult("")); err != nil {
+		t.Fatalf("設定ファイルの生成に失敗: %v", err)
+	}
+	if !strings.Contains(out.String(), "[https://github.com/org/rules]") {
+		t.Errorf("ベースリポジトリの候補が表示されていません: %s", out.String())
+	}
+
+	cfg := loadGenerated(t, configFile)
+	if cfg.BaseRepo != "https://github.com/org/rules" || !reflect.DeepEqual(cfg.Files, []string{"CLAUDE.md"}) {
+		t.Errorf("設定が一致しません: %s %v", cfg.BaseRepo, cfg.Files)
+	}
+}
+
+func TestRunNonInteractive(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, ".cursor/rules/general.mdc")
+	configFile := filepath.Join(dir, ".ruleforge.yaml")
+	client := newTestClient(t)
+
+	// --yes では入力を求めずに検出したファイルを使う
+	opts := Options{Output: configFile, BaseRepo: "org/rules", Yes: true, Dir: dir, In: strings.NewReader(""), Out: &bytes.Buffer{}}
+	if err := run(context.Background(), client, &config.Config{}, opts, output.NewResult("")); err != nil {
+		t.Fatalf("設定ファイルの生成に失敗: %v", err)
+	}
+	cfg := loadGenerated(t, configFile)
+	if !reflect.DeepEqual(cfg.Files, []string{".cursor/rules/general.mdc"}) {
+		t.Errorf("Files: 実際の値 %v", cfg.Files)
+	}
+	// 配置は空の値ではなく flat を明示する
+	if data, _ := os.ReadFile(configFile); !strings.Contains(string(data), "\nlayout: flat\n") {
+		t.Errorf("layout: flat が書き込まれていません:\n%s", data)
+	}
+
+	// 既存の設定ファイルは --force がなければ上書きしない
+	opts.Files = []string{"AGENTS.md"}
+	err := run(context.Background(), client, &config.Config{}, opts, output.NewResult(""))
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("既存ファイルでエラーが期待されました: %v", err)
+	}
+	opts.Force = true
+	if err := run(context.Background(), client, &config.Config{}, opts, output.NewResult("")); err != nil {
+		t.Fatalf("上書きに失敗: %v", err)
+	}
+	if cfg := loadGenerated(t, configFile); !reflect.DeepEqual(cfg.Files, []string{"AGENTS.md"}) {
+		t.Errorf("上書き後の Files: 実際の値 %v", cfg.Files)
+	}
+
+	// アクセスできないベースリポジトリやベースリポジトリの未指定はエラー
+	for _, baseRepo := range []string{"org/missing", ""} {
+		opts.BaseRepo = baseRepo
+		if err := run(context.Background(), client, &config.Config{}, opts, output.NewResult("")); err == nil {
+			t.Errorf("ベースリポジトリ %q でエラーが期待されましたが成功しました", baseRepo)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/drift"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+// Execute はローカルの対象ファイルがベースリポジトリのルールと一致しているかを表示し、ファイルごとの結果を返す
+// ローカルのファイルは変更しない
+func Execute(ctx context.Context, cfg *config.Config, out io.Writer) ([]drift.FileStatus, error) {
+	owner, repo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
+	if err != nil {
+		return nil, fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
+	}
+
+	return run(ctx, &download.Source{Client: client, Owner: owner, Repo: repo}, cfg, out)
+}
+
+func run(ctx context.Context, src *download.Source, cfg *config.Config, out io.Writer) ([]drift.FileStatus, error) {
+	statuses, err := drift.CompareLocal(ctx, src, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.State, s.Path, s.RemotePath)
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// Record はファイルごとの結果を res に記録する
+// SHA はベースリポジトリ側の内容（download が書き込む内容）のもので、ファイルが取得できなかった場合は空になる
+func Record(res *output.Result, cfg *config.Config, statuses []drift.FileStatus) {
+	for _, s := range statuses {
+		f := output.File{Path: filepath.Join(cfg.LocalDir, s.Path), RemotePath: s.RemotePath, Status: s.State}
+		if s.Expected != nil {
+			f.SHA = gitutil.BlobSHA(s.Expected)
+		}
+		res.Files = append(res.Files, f)
+	}
+}
+
+// Summary は複数プロジェクトの結果の状態ごとのファイル数
+type Summary map[string]int
+
+// Add はファイルごとの結果を集計に加える
+func (s Summary) Add(statuses []drift.FileStatus) {
+	for _, st := range statuses {
+		s[st.State]++
+	}
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("最新: %d / 差分あり: %d / ローカルになし: %d",
+		s[drift.StateUpToDate], s[drift.StateDrifted], s[drift.StateMissing])
+}
@@ -0,0 +1,84 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+	"github.com/hiroyannnn/ruleforge/internal/config"
+	"github.com/hiroyannnn/ruleforge/internal/download"
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+)
+
+func TestRun(t *testing.T) {
+	remote := map[string]string{
+		"/repos/org/rules/contents/CLAUDE.md":              "general",
+		"/repos/org/rules/contents/mono/billing/AGENTS.md": "billing",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		content, ok := remote[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("general"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		RepoName:    "mono",
+		ProjectPath: "billing",
+		LocalDir:    dir,
+		Files:       []string{"CLAUDE.md", "AGENTS.md"},
+	}
+
+	var out bytes.Buffer
+	statuses, err := run(context.Background(), &download.Source{Client: client, Owner: "org", Repo: "rules"}, cfg, &out)
+	if err != nil {
+		t.Fatalf("状態の取得に失敗: %v", err)
+	}
+	for _, want := range []string{"up-to-date  CLAUDE.md  CLAUDE.md", "missing     AGENTS.md  mono/billing/AGENTS.md"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+
+	summary := Summary{}
+	summary.Add(statuses)
+	summary.Add(statuses)
+	if got, want := summary.String(), "最新: 2 / 差分あり: 0 / ローカルになし: 2"; got != want {
+		t.Errorf("集計: 期待値 %q, 実際の値 %q", want, got)
+	}
+
+	res := output.NewResult("billing")
+	Record(res, cfg, statuses)
+	if len(res.Files) != 2 || res.Files[0].Path != filepath.Join(dir, "CLAUDE.md") || res.Files[0].SHA != gitutil.BlobSHA([]byte("general")) {
+		t.Errorf("記録した結果が期待と異なります: %+v", res.Files)
+	}
+	if res.Files[1].Status != "missing" || res.Files[1].RemotePath != "mono/billing/AGENTS.md" {
+		t.Errorf("ローカルにないファイルの結果が期待と異なります: %+v", res.Files[1])
+	}
+}
@@ -3,19 +3,43 @@ package updategeneral
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/google/go-github/v60/github"
 	"github.com/hiroyannnn/ruleforge/internal/config"
-	"golang.org/x/oauth2"
+	"github.com/hiroyannnn/ruleforge/internal/ghclient"
+	"github.com/hiroyannnn/ruleforge/internal/mdsection"
+	"github.com/hiroyannnn/ruleforge/internal/output"
+	"github.com/hiroyannnn/ruleforge/internal/prompt"
+	"github.com/hiroyannnn/ruleforge/internal/publish"
 )
 
-// Execute はgeneral設定更新処理を実行
-func Execute(cfg *config.Config) error {
-	if cfg.GitHubToken == "" {
+// Options は update-general の実行オプション
+type Options struct {
+	// general のファイルに反映するセクションの見出し（空の場合はファイル全体を上書き）
+	Sections []string
+
+	// 反映するセクションを対話的に選択する
+	Pick bool
+
+	// 対話的な選択の入出力（nilの場合は標準入出力）
+	In  io.Reader
+	Out io.Writer
+}
+
+// Execute はgeneral設定更新処理を実行し、ファイルごとの結果とプルリクエストを返す（失敗した場合も途中までの結果を返す）
+func Execute(ctx context.Context, cfg *config.Config, opts Options) (*output.Result, error) {
+	res := output.NewResult("")
+	return res, res.Fail(execute(ctx, cfg, opts, res))
+}
+
+func execute(ctx context.Context, cfg *config.Config, opts Options, res *output.Result) error {
+	if !cfg.HasCredentials() {
 		return fmt.Errorf("GitHub APIトークンが設定されていません。環境変数 GITHUB_TOKEN を設定するか、設定ファイルで指定してください")
 	}
 
@@ -33,51 +57,46 @@ func Execute(cfg *config.Config) error {
 		return fmt.Errorf("GitHubクライアントの初期化に失敗: %w", err)
 	}
 
-	ctx := context.Background()
-
-	// ベースブランチ（通常は main または master）を取得
-	repository, _, err := client.Repositories.Get(ctx, owner, repo)
-	if err != nil {
-		return fmt.Errorf("リポジトリ情報の取得に失敗: %w", err)
-	}
-	baseBranch := repository.GetDefaultBranch()
-
-	// ベースブランチのリファレンスを取得
-	baseRef, _, err := client.Git.GetRef(ctx, owner, repo, "refs/heads/"+baseBranch)
-	if err != nil {
-		return fmt.Errorf("ベースブランチのリファレンス取得に失敗: %w", err)
-	}
-
-	// 新しいブランチを作成
+	// 作業用ブランチ名
 	branchName := "update-general-" + cfg.BranchName
-	if cfg.RepoName != "" {
+	if prefix := cfg.BranchPrefix(); prefix != "" {
 		// リポジトリ名をプレフィックスとして追加（PRのタイトル識別用）
-		branchName = fmt.Sprintf("%s-%s", cfg.RepoName, branchName)
+		branchName = fmt.Sprintf("%s-%s", prefix, branchName)
 	}
 
-	newRef := &github.Reference{
-		Ref:    github.String("refs/heads/" + branchName),
-		Object: baseRef.Object,
+	in, out := opts.In, opts.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
 	}
+	prompter := prompt.New(in, out)
+	sectionMode := len(opts.Sections) > 0 || opts.Pick
+	found := map[string]bool{}
 
-	_, _, err = client.Git.CreateRef(ctx, owner, repo, newRef)
-	if err != nil {
-		if !strings.Contains(err.Error(), "Reference already exists") {
-			return fmt.Errorf("ブランチの作成に失敗: %w", err)
+	// セクション単位の場合、以前の実行でプルリクエストに反映したセクションを残すため、
+	// 作業用ブランチがあればその内容にマージする
+	var currentRef string
+	if sectionMode {
+		currentRef, err = workingBranchRef(ctx, client, owner, repo, branchName)
+		if err != nil {
+			return err
 		}
-		log.Printf("ブランチ '%s' は既に存在します。既存のブランチに追加します", branchName)
-	} else {
-		log.Printf("ブランチ '%s' を作成しました", branchName)
 	}
 
-	// 各ファイルをアップロード
+	// アップロードするファイルを収集
+	var files []publish.File
+	var partial bool
 	for _, filePath := range cfg.Files {
 		// ローカルファイルパス
 		localFilePath := filepath.Join(cfg.LocalDir, filePath)
 
 		// ファイルが存在するか確認
 		if _, err := os.Stat(localFilePath); os.IsNotExist(err) {
-			log.Printf("警告: ファイル '%s' が見つかりません。スキップします", localFilePath)
+			res.Warn("ファイル '%s' が見つかりません。スキップします", localFilePath)
+			res.Files = append(res.Files, output.File{Path: localFilePath, Status: output.StatusSkipped})
+			partial = true
 			continue
 		}
 
@@ -90,127 +109,140 @@ func Execute(cfg *config.Config) error {
 		// ファイルのアップロード先パス (generalディレクトリ)
 		targetPath := filepath.Join("general", filePath)
 
-		// 既存ファイルの情報を取得（SHA取得のため）
-		var existingSHA string
-		fileContent, _, _, err := client.Repositories.GetContents(
-			ctx,
-			owner,
-			repo,
-			targetPath,
-			&github.RepositoryContentGetOptions{Ref: branchName},
-		)
-
-		if err == nil && fileContent != nil {
-			existingSHA = fileContent.GetSHA()
-		}
+		// セクション単位の場合は、既存の general のファイルに見出し単位でマージする
+		if sectionMode {
+			titles := opts.Sections
+			if opts.Pick {
+				titles, err = pickSections(prompter, localFilePath, content, res)
+				if err != nil {
+					return err
+				}
+			}
 
-		// ファイルをアップロード（更新または作成）
-		opts := &github.RepositoryContentFileOptions{
-			Message: github.String(cfg.Message),
-			Content: content,
-			Branch:  github.String(branchName),
-		}
+			current, err := ghclient.GetFile(ctx, client, owner, repo, targetPath, currentRef)
+			if err != nil {
+				return err
+			}
 
-		if existingSHA != "" {
-			opts.SHA = github.String(existingSHA)
+			var merged []string
+			content, merged = mergeSections(current, content, titles)
+			if len(merged) == 0 {
+				res.Files = append(res.Files, output.File{Path: localFilePath, Status: output.StatusSkipped})
+				partial = true
+				continue
+			}
+			for _, title := range merged {
+				found[title] = true
+			}
+			log.Printf("ファイル '%s' のセクション %s を '%s' にマージします", localFilePath, strings.Join(merged, ", "), targetPath)
 		}
 
-		if cfg.Verbose {
-			log.Printf("ファイル '%s' をパス '%s' にアップロード中...", localFilePath, targetPath)
-		}
+		files = append(files, publish.File{Path: targetPath, Content: content, Source: localFilePath})
+	}
 
-		_, _, err = client.Repositories.CreateFile(ctx, owner, repo, targetPath, opts)
-		if err != nil {
-			return fmt.Errorf("ファイル '%s' のアップロードに失敗: %w", targetPath, err)
+	for _, title := range opts.Sections {
+		if !found[title] {
+			return fmt.Errorf("セクション '%s' がローカルのファイルに見つかりません", title)
 		}
-
-		log.Printf("ファイル '%s' をアップロードしました: %s", localFilePath, targetPath)
 	}
 
-	// プルリクエストを作成
+	// プルリクエストのタイトルと本文
 	title := fmt.Sprintf("[General] %s", cfg.Message)
-	if cfg.RepoName != "" {
-		title = fmt.Sprintf("[General][%s] %s", cfg.RepoName, cfg.Message)
+	if repoDir := cfg.RepoDir(); repoDir != "" {
+		title = fmt.Sprintf("[General][%s] %s", repoDir, cfg.Message)
 	}
 
-	body := fmt.Sprintf("このPRは %s から自動生成されました。\n\ngeneral設定の更新を含みます。", cfg.RepoName)
+	body := fmt.Sprintf("このPRは %s から自動生成されました。\n\ngeneral設定の更新を含みます。", cfg.RepoDir())
+	if len(found) > 0 {
+		titles := make([]string, 0, len(found))
+		for title := range found {
+			titles = append(titles, title)
+		}
+		sort.Strings(titles)
+		body += "\n\n更新するセクション:\n- " + strings.Join(titles, "\n- ")
+	}
+
+	// ブランチにコミットしてプルリクエストを作成
+	result, err := publish.Run(ctx, client, &publish.Request{
+		Owner:       owner,
+		Repo:        repo,
+		Branch:      branchName,
+		Files:       files,
+		Partial:     partial,
+		Message:     cfg.Message,
+		Title:       title,
+		Body:        body,
+		PullRequest: cfg.PullRequest,
+		Verbose:     cfg.Verbose,
+	})
+	if err != nil {
+		return err
+	}
+	result.Record(res, files)
+	return nil
+}
 
-	pr := &github.NewPullRequest{
-		Title:               github.String(title),
-		Head:                github.String(branchName),
-		Base:                github.String(baseBranch),
-		Body:                github.String(body),
-		MaintainerCanModify: github.Bool(true),
+// pickSections はファイルの見出しを一覧表示し、反映するセクションを選択させる
+func pickSections(p *prompt.Prompter, localFilePath string, content []byte, res *output.Result) ([]string, error) {
+	sections := mdsection.Parse(content)
+	if len(sections) == 0 {
+		res.Warn("ファイル '%s' に見出しがありません。スキップします", localFilePath)
+		return nil, nil
 	}
 
-	pullRequest, _, err := client.PullRequests.Create(ctx, owner, repo, pr)
+	selected, err := p.Select(fmt.Sprintf("'%s' から general に反映するセクションを選択してください", localFilePath), mdsection.Titles(sections))
 	if err != nil {
-		// PR作成エラーチェック - 既に同じブランチでPRが存在する可能性がある
-		if strings.Contains(err.Error(), "pull request already exists") {
-			log.Printf("警告: このブランチからのPRは既に存在します")
-
-			// 既存PRを探す
-			prs, _, listErr := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
-				Head:  branchName,
-				Base:  baseBranch,
-				State: "open",
-			})
-
-			if listErr == nil && len(prs) > 0 {
-				log.Printf("既存のPR #%d にコンテンツが追加されました: %s", prs[0].GetNumber(), prs[0].GetHTMLURL())
-				return nil
-			}
+		return nil, err
+	}
 
-			return fmt.Errorf("PR作成に失敗し、既存のPRも特定できません: %w", err)
+	titles := make([]string, len(selected))
+	for i, idx := range selected {
+		titles[i] = sections[idx].Title
+	}
+	return titles, nil
+}
+
+// mergeSections はローカルのファイルから指定した見出しのセクションを取り出し、
+// general のファイルの同じ見出しを置き換える（なければ末尾に追加する）
+// ローカルのファイルに見つかった見出しを合わせて返す
+func mergeSections(general, local []byte, titles []string) ([]byte, []string) {
+	var merged []string
+	for _, title := range titles {
+		section, ok := mdsection.Find(local, title)
+		if !ok {
+			continue
 		}
-		return fmt.Errorf("プルリクエストの作成に失敗: %w", err)
+		general = mdsection.Upsert(general, section)
+		merged = append(merged, title)
 	}
+	return general, merged
+}
 
-	log.Printf("プルリクエスト #%d を作成しました: %s", pullRequest.GetNumber(), pullRequest.GetHTMLURL())
-	return nil
+// workingBranchRef は作業用ブランチがあればその名前を、なければデフォルトブランチを表す空文字列を返す
+func workingBranchRef(ctx context.Context, client *github.Client, owner, repo, branch string) (string, error) {
+	_, _, err := client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	if ghclient.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("作業用ブランチ '%s' の確認に失敗: %w", branch, err)
+	}
+	return branch, nil
 }
 
 // initGitHubClient はGitHubクライアントを初期化し、所有者とリポジトリ名を抽出
 func initGitHubClient(cfg *config.Config) (*github.Client, string, string, error) {
 	// リポジトリURLからオーナーとリポジトリ名を抽出
-	owner, repo, err := parseRepoURL(cfg.BaseRepo)
+	owner, repo, err := ghclient.ParseRepoURL(cfg.BaseRepo)
 	if err != nil {
 		return nil, "", "", err
 	}
 
-	// GitHub API認証
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: cfg.GitHubToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-
-	return client, owner, repo, nil
-}
-
-// parseRepoURL はGitHubリポジトリURLから所有者とリポジトリ名を抽出
-func parseRepoURL(repoURL string) (string, string, error) {
-	// URLからgithub.comドメイン以降の部分を抽出
-	repoURL = strings.TrimSuffix(repoURL, "/")
-	repoURL = strings.TrimSuffix(repoURL, ".git")
-
-	var parts []string
-
-	// https://github.com/owner/repo 形式
-	if strings.HasPrefix(repoURL, "https://github.com/") {
-		parts = strings.Split(strings.TrimPrefix(repoURL, "https://github.com/"), "/")
-	} else if strings.HasPrefix(repoURL, "git@github.com:") {
-		// git@github.com:owner/repo.git 形式
-		parts = strings.Split(strings.TrimPrefix(repoURL, "git@github.com:"), "/")
-	} else {
-		// owner/repo 形式（短縮形）
-		parts = strings.Split(repoURL, "/")
-	}
-
-	if len(parts) < 2 {
-		return "", "", fmt.Errorf("無効なリポジトリURL形式: %s", repoURL)
+	// GitHub API認証とリトライを組み込んだクライアントを作成
+	client, err := ghclient.NewClient(cfg)
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	return parts[0], parts[1], nil
+	return client, owner, repo, nil
 }
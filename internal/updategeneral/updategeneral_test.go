@@ -1,12 +1,20 @@
 package updategeneral
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/hiroyannnn/ruleforge/internal/gitutil"
+
 	"github.com/hiroyannnn/ruleforge/internal/config"
 )
 
@@ -118,14 +126,14 @@ func TestExecute(t *testing.T) {
 
 	// テスト用の設定
 	cfg := &config.Config{
-		BaseRepo:    "https://github.com/testowner/testrepo",
-		Files:       []string{".cursor/rules.md"},
-		LocalDir:    tempDir,
-		GitHubToken: "test-token",
-		Message:     "Update general rules",
-		Verbose:     true,
-		BranchName:  "test-branch",
-		RepoName:    "testrepo",
+		BaseRepo:   "https://github.com/testowner/testrepo",
+		Files:      []string{".cursor/rules.md"},
+		LocalDir:   tempDir,
+		Auth:       config.AuthConfig{GitHubToken: "test-token"},
+		Message:    "Update general rules",
+		Verbose:    true,
+		BranchName: "test-branch",
+		RepoName:   "testrepo",
 	}
 
 	// アップロード処理を実行
@@ -135,48 +143,151 @@ func TestExecute(t *testing.T) {
 
 	t.Skip("このテストはモックが正しく設定されていないためスキップします")
 
-	err = Execute(cfg)
+	_, err = Execute(context.Background(), cfg, Options{})
 	if err != nil {
 		t.Fatalf("general更新処理に失敗: %v", err)
 	}
 }
 
-func TestParseRepoURL(t *testing.T) {
-	// 様々なURL形式を試験
-	testCases := []struct {
-		url      string
-		owner    string
-		repo     string
-		hasError bool
-	}{
-		{"https://github.com/owner/repo", "owner", "repo", false},
-		{"https://github.com/owner/repo.git", "owner", "repo", false},
-		{"https://github.com/owner/repo/", "owner", "repo", false},
-		{"git@github.com:owner/repo.git", "owner", "repo", false},
-		{"owner/repo", "owner", "repo", false},
-		{"", "", "", true},
-		{"invalid-url", "", "", true},
+func TestMergeSections(t *testing.T) {
+	general := []byte("# General rules\n\n## Style\n\nUse gofmt.\n\n## Testing\n\nWrite tests.\n")
+	local := []byte("# My rules\n\n## Style\n\nUse goimports.\n\n## Error handling\n\nWrap errors.\n\n## Local only\n\nSecret.\n")
+
+	merged, titles := mergeSections(general, local, []string{"Style", "Error handling", "Unknown"})
+
+	expected := "# General rules\n\n## Style\n\nUse goimports.\n\n## Testing\n\nWrite tests.\n\n## Error handling\n\nWrap errors.\n"
+	if string(merged) != expected {
+		t.Errorf("マージ結果が一致しません:\n%s", merged)
+	}
+	if len(titles) != 2 || titles[0] != "Style" || titles[1] != "Error handling" {
+		t.Errorf("マージしたセクションが一致しません: %v", titles)
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.url, func(t *testing.T) {
-			owner, repo, err := parseRepoURL(tc.url)
+	// general のファイルがまだない場合はセクションだけのファイルになる
+	merged, _ = mergeSections(nil, local, []string{"Error handling"})
+	if string(merged) != "## Error handling\n\nWrap errors.\n" {
+		t.Errorf("新規ファイルの内容が一致しません:\n%s", merged)
+	}
+}
 
-			if tc.hasError {
-				if err == nil {
-					t.Errorf("エラーが期待されましたが、成功しました: %s, %s", owner, repo)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("予期しないエラー: %v", err)
-				}
-				if owner != tc.owner {
-					t.Errorf("owner: 期待値 %s, 実際の値 %s", tc.owner, owner)
-				}
-				if repo != tc.repo {
-					t.Errorf("repo: 期待値 %s, 実際の値 %s", tc.repo, repo)
-				}
+// branchServer はブランチごとのファイルの内容を保持し、update-general に必要な API を返すモックサーバー
+type branchServer struct {
+	mu       sync.Mutex
+	branches map[string]map[string]string // ブランチ名 -> パス -> 内容
+}
+
+func newBranchServer(t *testing.T, base map[string]string) (*branchServer, string) {
+	t.Helper()
+	bs := &branchServer{branches: map[string]map[string]string{"main": base}}
+	server := httptest.NewServer(http.HandlerFunc(bs.serve))
+	t.Cleanup(server.Close)
+	return bs, server.URL
+}
+
+func (bs *branchServer) serve(w http.ResponseWriter, r *http.Request) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}
+
+	const prefix = "/repos/org/rules"
+	p := strings.TrimPrefix(r.URL.Path, prefix)
+	switch {
+	case r.Method == "GET" && p == "":
+		_, _ = w.Write([]byte(`{"default_branch": "main"}`))
+	case r.Method == "GET" && strings.HasPrefix(p, "/git/ref/heads/"):
+		branch := strings.TrimPrefix(p, "/git/ref/heads/")
+		if _, ok := bs.branches[branch]; !ok {
+			notFound()
+			return
+		}
+		_, _ = w.Write([]byte(`{"ref": "refs/heads/` + branch + `", "object": {"sha": "` + branch + `"}}`))
+	case r.Method == "POST" && p == "/git/refs":
+		var ref struct{ Ref string }
+		_ = json.NewDecoder(r.Body).Decode(&ref)
+		branch := strings.TrimPrefix(ref.Ref, "refs/heads/")
+		if _, ok := bs.branches[branch]; ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message": "Reference already exists"}`))
+			return
+		}
+		files := map[string]string{}
+		for k, v := range bs.branches["main"] {
+			files[k] = v
+		}
+		bs.branches[branch] = files
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ref": "` + ref.Ref + `"}`))
+	case r.Method == "GET" && strings.HasPrefix(p, "/compare/"):
+		_, _ = w.Write([]byte(`{"behind_by": 0}`))
+	case strings.HasPrefix(p, "/contents/"):
+		filePath := strings.TrimPrefix(p, "/contents/")
+		if r.Method == "PUT" {
+			var body struct {
+				Content string
+				Branch  string
 			}
-		})
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			content, _ := base64.StdEncoding.DecodeString(body.Content)
+			bs.branches[body.Branch][filePath] = string(content)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"content": {}}`))
+			return
+		}
+		ref := r.URL.Query().Get("ref")
+		if ref == "" {
+			ref = "main"
+		}
+		content, ok := bs.branches[ref][filePath]
+		if !ok {
+			notFound()
+			return
+		}
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "` + encoded + `", "sha": "` + gitutil.BlobSHA([]byte(content)) + `"}`))
+	case r.Method == "POST" && p == "/pulls":
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 1, "html_url": "https://github.com/org/rules/pull/1"}`))
+	default:
+		notFound()
+	}
+}
+
+func TestExecuteSectionsKeepsEarlierSections(t *testing.T) {
+	t.Setenv("RULEFORGE_CONFIG_DIR", t.TempDir())
+	bs, apiURL := newBranchServer(t, map[string]string{
+		"general/AGENTS.md": "# General\n\n## Style\n\nUse gofmt.\n",
+	})
+
+	dir := t.TempDir()
+	local := "# Local\n\n## Style\n\nUse goimports.\n\n## Testing\n\nWrite tests.\n"
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte(local), 0644); err != nil {
+		t.Fatalf("ファイルの作成に失敗: %v", err)
+	}
+	cfg := &config.Config{
+		BaseRepo:   "org/rules",
+		Files:      []string{"AGENTS.md"},
+		LocalDir:   dir,
+		Auth:       config.AuthConfig{GitHubToken: "test-token", APIURL: apiURL},
+		Message:    "Update general rules",
+		BranchName: "rules",
+		RepoName:   "app",
+	}
+
+	// 1回目で Style、2回目で Testing を反映しても、プルリクエストには両方が残る
+	for _, section := range []string{"Style", "Testing"} {
+		if _, err := Execute(context.Background(), cfg, Options{Sections: []string{section}}); err != nil {
+			t.Fatalf("セクション %s の反映に失敗: %v", section, err)
+		}
+	}
+
+	got := bs.branches["app-update-general-rules"]["general/AGENTS.md"]
+	expected := "# General\n\n## Style\n\nUse goimports.\n\n## Testing\n\nWrite tests.\n"
+	if got != expected {
+		t.Errorf("作業用ブランチの内容が一致しません:\n%s", got)
 	}
 }
@@ -1,6 +1,7 @@
 package upload
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -118,14 +119,14 @@ func TestExecute(t *testing.T) {
 
 	// テスト用の設定
 	cfg := &config.Config{
-		BaseRepo:    "https://github.com/testowner/testrepo",
-		Files:       []string{".cursor/rules.md"},
-		LocalDir:    tempDir,
-		GitHubToken: "test-token",
-		Message:     "Update rules",
-		Verbose:     true,
-		BranchName:  "test-branch",
-		RepoName:    "testrepo",
+		BaseRepo:   "https://github.com/testowner/testrepo",
+		Files:      []string{".cursor/rules.md"},
+		LocalDir:   tempDir,
+		Auth:       config.AuthConfig{GitHubToken: "test-token"},
+		Message:    "Update rules",
+		Verbose:    true,
+		BranchName: "test-branch",
+		RepoName:   "testrepo",
 	}
 
 	// アップロード処理を実行
@@ -135,48 +136,8 @@ func TestExecute(t *testing.T) {
 
 	t.Skip("このテストはモックが正しく設定されていないためスキップします")
 
-	err = Execute(cfg)
+	_, err = Execute(context.Background(), cfg)
 	if err != nil {
 		t.Fatalf("アップロード処理に失敗: %v", err)
 	}
 }
-
-func TestParseRepoURL(t *testing.T) {
-	// 様々なURL形式を試験
-	testCases := []struct {
-		url      string
-		owner    string
-		repo     string
-		hasError bool
-	}{
-		{"https://github.com/owner/repo", "owner", "repo", false},
-		{"https://github.com/owner/repo.git", "owner", "repo", false},
-		{"https://github.com/owner/repo/", "owner", "repo", false},
-		{"git@github.com:owner/repo.git", "owner", "repo", false},
-		{"owner/repo", "owner", "repo", false},
-		{"", "", "", true},
-		{"invalid-url", "", "", true},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.url, func(t *testing.T) {
-			owner, repo, err := parseRepoURL(tc.url)
-
-			if tc.hasError {
-				if err == nil {
-					t.Errorf("エラーが期待されましたが、成功しました: %s, %s", owner, repo)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("予期しないエラー: %v", err)
-				}
-				if owner != tc.owner {
-					t.Errorf("owner: 期待値 %s, 実際の値 %s", tc.owner, owner)
-				}
-				if repo != tc.repo {
-					t.Errorf("repo: 期待値 %s, 実際の値 %s", tc.repo, repo)
-				}
-			}
-		})
-	}
-}